@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/vprashar2929/integration-test/pkg/api"
+	"github.com/vprashar2929/integration-test/pkg/argocd"
+	"github.com/vprashar2929/integration-test/pkg/audit"
+	"github.com/vprashar2929/integration-test/pkg/cluster"
+	"github.com/vprashar2929/integration-test/pkg/customresource"
+	"github.com/vprashar2929/integration-test/pkg/daemonset"
+	"github.com/vprashar2929/integration-test/pkg/deployment"
+	"github.com/vprashar2929/integration-test/pkg/gateway"
+	"github.com/vprashar2929/integration-test/pkg/helm"
+	"github.com/vprashar2929/integration-test/pkg/hpa"
+	"github.com/vprashar2929/integration-test/pkg/ingress"
+	"github.com/vprashar2929/integration-test/pkg/logger"
+	"github.com/vprashar2929/integration-test/pkg/metrics"
+	"github.com/vprashar2929/integration-test/pkg/olm"
+	"github.com/vprashar2929/integration-test/pkg/pdb"
+	"github.com/vprashar2929/integration-test/pkg/pod"
+	"github.com/vprashar2929/integration-test/pkg/probe"
+	"github.com/vprashar2929/integration-test/pkg/references"
+	"github.com/vprashar2929/integration-test/pkg/replicaset"
+	"github.com/vprashar2929/integration-test/pkg/service"
+	"github.com/vprashar2929/integration-test/pkg/statefulset"
+)
+
+// check is a single checker of a run, what is used in the error message when it fails
+type check struct {
+	name string
+	what string
+	run  func() error
+}
+
+// newChecks returns the enabled checkers in the order they run against the namespaces
+func newChecks(cfg *Config, namespaces []string) []check {
+	var checks []check
+	if checkCluster {
+		checks = append(checks, check{"cluster", "cluster", func() error {
+			defer logger.AppLog.LogSeperator()
+			return cluster.CheckCluster(cfg.ClientSet, cfg.ClientSet.Discovery().RESTClient(), interval, timeout)
+		}})
+	}
+	// missing configmaps and secrets are reported before waiting on pods that cannot start without them
+	checks = append(checks, check{"references", "configmap and secret references", func() error {
+		return references.CheckReferences(namespaces, cfg.ClientSet, interval, timeout)
+	}})
+	if runAudit {
+		checks = append(checks, check{"audit", "workload audit", func() error {
+			return audit.CheckAudit(namespaces, auditFailSeverity, cfg.ClientSet)
+		}})
+	}
+	checks = append(checks,
+		check{"deployment", "deployements", func() error {
+			return deployment.CheckDeployments(namespaces, cfg.ClientSet, interval, timeout)
+		}},
+		check{"statefulset", "statefulsets", func() error {
+			return statefulset.CheckStatefulSets(namespaces, cfg.ClientSet, interval, timeout)
+		}},
+		check{"service", "services", func() error {
+			return service.CheckServices(namespaces, cfg.ClientSet, interval, timeout)
+		}},
+		check{"replicaset", "replicasets", func() error {
+			return replicaset.CheckReplicaSets(namespaces, checkDeploymentReplicaSets, cfg.ClientSet, interval, timeout)
+		}},
+		check{"daemonset", "daemonsets", func() error {
+			return daemonset.CheckDaemonSets(namespaces, cfg.ClientSet, interval, timeout)
+		}},
+	)
+	// pods are checked once the workloads settled, running digests are only known after a rollout
+	if cfg.ImagePolicy != nil {
+		checks = append(checks, check{"image", "image policy", func() error {
+			return pod.CheckImages(namespaces, cfg.ImagePolicy, cfg.ClientSet)
+		}})
+	}
+	if verifyProbes {
+		checks = append(checks, check{"probe", "probes", func() error {
+			return probe.CheckProbes(namespaces, cfg.Transport, cfg.ClientSet, interval, timeout)
+		}})
+	}
+	if len(cfg.ExecAssertions) > 0 {
+		checks = append(checks, check{"exec", "exec assertions", func() error {
+			return pod.CheckExecAssertions(namespaces, cfg.ExecAssertions, cfg.Executor, cfg.ClientSet, interval, timeout)
+		}})
+	}
+	checks = append(checks,
+		check{"hpa", "horizontalpodautoscalers", func() error {
+			return hpa.CheckHPAs(namespaces, cfg.ClientSet, interval, timeout)
+		}},
+		check{"pdb", "poddisruptionbudgets", func() error {
+			return pdb.CheckPDBs(namespaces, cfg.ClientSet, interval, timeout)
+		}},
+		check{"ingress", "ingresses", func() error {
+			return ingress.CheckIngresses(namespaces, cfg.ClientSet, probeHTTP, interval, timeout)
+		}},
+		check{"route", "routes", func() error {
+			return ingress.CheckRoutes(namespaces, cfg.Dynamic, cfg.ClientSet, probeHTTP, interval, timeout)
+		}},
+		check{"gateway", "gateways", func() error {
+			return gateway.CheckGateways(namespaces, cfg.Dynamic, cfg.ClientSet, interval, timeout)
+		}},
+		check{"olm", "operators", func() error {
+			return olm.CheckOperators(namespaces, cfg.Dynamic, cfg.ClientSet, interval, timeout)
+		}},
+		check{"helm", "helm releases", func() error {
+			return helm.CheckReleases(namespaces, cfg.ClientSet, interval, timeout)
+		}},
+		check{"argocd", "argocd applications", func() error {
+			return argocd.CheckApplications(namespaces, argocdRevision, argocdCheckDestination, cfg.Dynamic, cfg.ClientSet, interval, timeout)
+		}},
+		check{"customresource", "custom resources", func() error {
+			return customresource.CheckCustomResources(namespaces, customResources, cfg.Dynamic, interval, timeout)
+		}},
+	)
+	return checks
+}
+
+// runCheck runs a single check and records its metrics
+func runCheck(c check) error {
+	start := time.Now()
+	err := c.run()
+	metrics.ObserveChecker(c.name, time.Since(start), err)
+	if err != nil {
+		logger.AppLog.LogError("cannot validate %s. reason: %v\n", c.what, err)
+	}
+	return err
+}
+
+// runChecks runs every check and returns the errors of the failed ones, prefixed with the checker name
+func runChecks(checks []check) []error {
+	var errs []error
+	for _, c := range checks {
+		if err := runCheck(c); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", c.name, err))
+		}
+	}
+	return errs
+}
+
+// notifyRun reports the outcome of a run of suite to the configured notifiers, a failed notification does not
+// fail the run
+func notifyRun(cfg *Config, namespaces, suite []string, errs []error) {
+	if cfg.Notifier == nil {
+		return
+	}
+	_ = cfg.Notifier.Report(namespaces, suite, errs)
+}
+
+// selectChecks keeps the checks named in suite, all of them when suite is empty
+func selectChecks(checks []check, suite []string) ([]check, error) {
+	if len(suite) == 0 {
+		return checks, nil
+	}
+	byName := make(map[string]check)
+	names := make([]string, 0, len(checks))
+	for _, c := range checks {
+		byName[c.name] = c
+		names = append(names, c.name)
+	}
+	selected := make([]check, 0, len(suite))
+	for _, name := range suite {
+		c, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown checker %q, enabled checkers are %s", name, strings.Join(names, ", "))
+		}
+		selected = append(selected, c)
+	}
+	return selected, nil
+}
+
+// suiteRunner runs the checks requested through the run API
+type suiteRunner struct {
+	cfg *Config
+}
+
+func (s suiteRunner) Validate(request api.Request) error {
+	_, err := selectChecks(newChecks(s.cfg, request.Namespaces), request.Suite)
+	return err
+}
+
+func (s suiteRunner) Run(ctx context.Context, request api.Request) []api.CheckResult {
+	checks, _ := selectChecks(newChecks(s.cfg, request.Namespaces), request.Suite)
+	metrics.BeginRun()
+	results := make([]api.CheckResult, 0, len(checks))
+	var errs []error
+	for _, c := range checks {
+		// a check skipped by shutdown fails the run, so it is not reported as passed or recovered
+		if ctx.Err() != nil {
+			results = append(results, api.CheckResult{Checker: c.name, Error: "not run: " + ctx.Err().Error()})
+			errs = append(errs, fmt.Errorf("%s: not run: %w", c.name, ctx.Err()))
+			continue
+		}
+		start := time.Now()
+		err := runCheck(c)
+		result := api.CheckResult{Checker: c.name, Passed: err == nil, Duration: time.Since(start).Seconds()}
+		if err != nil {
+			result.Error = err.Error()
+			errs = append(errs, fmt.Errorf("%s: %w", c.name, err))
+		}
+		results = append(results, result)
+	}
+	metrics.EndRun()
+	notifyRun(s.cfg, request.Namespaces, request.Suite, errs)
+	return results
+}
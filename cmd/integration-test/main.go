@@ -1,18 +1,31 @@
 package main
 
 import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 
 	"flag"
 	"time"
 
+	"github.com/vprashar2929/integration-test/pkg/api"
+	"github.com/vprashar2929/integration-test/pkg/audit"
 	"github.com/vprashar2929/integration-test/pkg/client"
-	"github.com/vprashar2929/integration-test/pkg/daemonset"
-	"github.com/vprashar2929/integration-test/pkg/deployment"
+	"github.com/vprashar2929/integration-test/pkg/customresource"
+	"github.com/vprashar2929/integration-test/pkg/daemon"
 	"github.com/vprashar2929/integration-test/pkg/logger"
-	"github.com/vprashar2929/integration-test/pkg/replicaset"
-	"github.com/vprashar2929/integration-test/pkg/service"
-	"github.com/vprashar2929/integration-test/pkg/statefulset"
+	"github.com/vprashar2929/integration-test/pkg/metrics"
+	"github.com/vprashar2929/integration-test/pkg/notify"
+	"github.com/vprashar2929/integration-test/pkg/pod"
+	"github.com/vprashar2929/integration-test/pkg/preflight"
+	"github.com/vprashar2929/integration-test/pkg/probe"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 )
 
@@ -20,6 +33,11 @@ const (
 	defaultInterval  = 1 * time.Minute
 	defaultTimeout   = 5 * time.Minute
 	defaultNamespace = "default"
+
+	defaultSchedule      = 5 * time.Minute
+	defaultListenAddress = ":8080"
+
+	defaultNotifyTimeout = 30 * time.Second
 )
 
 var (
@@ -29,15 +47,109 @@ var (
 	interval   time.Duration
 	timeout    time.Duration
 	errList    []error
+
+	checkDeploymentReplicaSets bool
+	probeHTTP                  bool
+	customResources            customResourceChecks
+	argocdRevision             string
+	argocdCheckDestination     bool
+	runPreflight               bool
+	generateRBAC               bool
+	serviceAccount             string
+	checkCluster               bool
+	disallowLatestTag          bool
+	allowedRegistries          string
+	requireImageDigest         bool
+	expectedDigests            expectedImageDigests
+	runAudit                   bool
+	auditFailSeverity          = audit.SeverityHigh
+	verifyProbes               bool
+	probeTransport             string
+	mode                       string
+	schedule                   time.Duration
+	listenAddress              string
+	runsDir                    string
+	execAssertionsFile         string
+	notifyOn                   string
+	notifyWebhookURL           string
+	notifyWebhookTemplate      string
+	notifySlackURL             string
+	notifySMTPAddress          string
+	notifySMTPFrom             string
+	notifySMTPTo               string
+	notifySMTPUsername         string
 )
 
+// customResourceChecks collects every --custom-resource flag into a list of checks
+type customResourceChecks []customresource.Check
+
+func (c *customResourceChecks) String() string {
+	specs := make([]string, 0, len(*c))
+	for _, check := range *c {
+		specs = append(specs, check.String())
+	}
+	return strings.Join(specs, ",")
+}
+
+func (c *customResourceChecks) Set(value string) error {
+	check, err := customresource.ParseCheck(value)
+	if err != nil {
+		return err
+	}
+	*c = append(*c, check)
+	return nil
+}
+
+// expectedImageDigests collects every --expected-image-digest flag keyed by image name
+type expectedImageDigests map[string]string
+
+func (e *expectedImageDigests) String() string {
+	specs := make([]string, 0, len(*e))
+	for name, digest := range *e {
+		specs = append(specs, name+"@"+digest)
+	}
+	return strings.Join(specs, ",")
+}
+
+func (e *expectedImageDigests) Set(value string) error {
+	name, digest, err := pod.ParseExpectedDigest(value)
+	if err != nil {
+		return err
+	}
+	if *e == nil {
+		*e = make(expectedImageDigests)
+	}
+	(*e)[name] = digest
+	return nil
+}
+
+// splitList splits a comma separated flag value and trims each entry, empty entries are rejected
+func splitList(value string) ([]string, error) {
+	var entries []string
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			return nil, fmt.Errorf("empty entry in %q", value)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
 type Config struct {
 	NsList     []string
 	KubeConfig string
 	ClientSet  kubernetes.Interface
+	Dynamic    dynamic.Interface
 	LogLevel   string
 	Interval   time.Duration
 	Timeout    time.Duration
+
+	Transport      probe.Transport
+	Executor       pod.Executor
+	ExecAssertions []pod.ExecAssertion
+	ImagePolicy    *pod.ImagePolicy
+	Notifier       *notify.Dispatcher
 }
 
 func init() {
@@ -46,6 +158,37 @@ func init() {
 	flag.DurationVar(&interval, "interval", defaultInterval, "Wait before retry status check again")
 	flag.DurationVar(&timeout, "timeout", defaultTimeout, "Timeout for retry")
 	flag.StringVar(&loglevel, "loglevel", "", "log level")
+	flag.BoolVar(&probeHTTP, "probe-http", false, "Probe hosts exposed by ingresses and routes over HTTP")
+	flag.BoolVar(&checkDeploymentReplicaSets, "check-deployment-replicasets", false, "Also validate the current revision replicaset of each deployment")
+	flag.StringVar(&argocdRevision, "argocd-revision", "", "Revision Argo CD applications are expected to be synced to")
+	flag.BoolVar(&argocdCheckDestination, "argocd-check-destination", false, "Also validate the workloads in the destination namespace of each Argo CD application deploying to this cluster")
+	flag.BoolVar(&checkCluster, "check-cluster", false, "Validate nodes, the API server, CoreDNS and kube-system pods before the namespace checks")
+	flag.BoolVar(&runPreflight, "preflight", false, "Verify the service account has every permission the checks need before running them")
+	flag.BoolVar(&generateRBAC, "generate-rbac", false, "Print the minimal Role and ClusterRole needed by the checks, along with their bindings, and exit")
+	flag.StringVar(&serviceAccount, "service-account", "", "Service account the roles printed by --generate-rbac are bound to as <namespace>/<name>, defaults to integration-test in the first monitored namespace")
+	flag.Var(&customResources, "custom-resource", "Custom resource to validate as <group>/<version>/<resource>[:<condition>[=<status>]] or <group>/<version>/<resource>:{<jsonpath>}=<value>. Can be repeated")
+	flag.BoolVar(&disallowLatestTag, "disallow-latest-tag", false, "Fail pods whose containers use the latest tag or no tag at all")
+	flag.StringVar(&allowedRegistries, "allowed-registries", "", "Comma separated registries pod images may be pulled from")
+	flag.BoolVar(&requireImageDigest, "require-image-digest", false, "Fail pods whose container images are not pinned by digest")
+	flag.Var(&expectedDigests, "expected-image-digest", "Digest the running image must have as <image>@<digest>, image without tag. Can be repeated")
+	flag.BoolVar(&runAudit, "audit", false, "Audit the pod templates of deployments, statefulsets and daemonsets for probes, resources and security settings")
+	flag.Var(&auditFailSeverity, "audit-fail-severity", "Audit findings at or above this severity fail the run, lower ones are warnings. One of low, medium, high, none")
+	flag.Func("audit-severity", "Override the severity of an audit rule as <rule>=<severity>. Can be repeated", audit.SetRuleSeverity)
+	flag.BoolVar(&verifyProbes, "verify-probes", false, "Execute the HTTP, TCP and gRPC readiness and liveness probes of running pods")
+	flag.StringVar(&probeTransport, "probe-transport", "auto", "How probes reach pods. proxy goes through the API server pod proxy, direct dials pod IPs from inside the cluster, portforward forwards a local port over SPDY and auto picks direct in-cluster and portforward with --kubeconfig")
+	flag.StringVar(&execAssertionsFile, "exec-assertions", "", "YAML file listing commands to run inside pods with their expected exit code and output")
+	flag.StringVar(&mode, "mode", "once", "once runs the checks a single time and exits, daemon re-runs them every --schedule and serves /metrics, /healthz and /readyz, server runs them on demand through the /runs API")
+	flag.DurationVar(&schedule, "schedule", defaultSchedule, "Wait between runs in daemon mode")
+	flag.StringVar(&listenAddress, "listen-address", defaultListenAddress, "Address the daemon and server modes listen on")
+	flag.StringVar(&runsDir, "runs-dir", "", "Directory the server mode keeps run history in, kept in memory when empty")
+	flag.StringVar(&notifyOn, "notify-on", "failure,recovery", "Comma separated runs to send notifications for. failure, recovery or always")
+	flag.StringVar(&notifyWebhookURL, "notify-webhook-url", "", "URL to post a JSON notification to")
+	flag.StringVar(&notifyWebhookTemplate, "notify-webhook-template", "", "Go template file rendering the webhook body, the notification is posted as JSON when empty")
+	flag.StringVar(&notifySlackURL, "notify-slack-url", "", "Slack compatible incoming webhook URL to post notifications to")
+	flag.StringVar(&notifySMTPAddress, "notify-smtp-address", "", "host:port of the SMTP server to mail notifications through")
+	flag.StringVar(&notifySMTPFrom, "notify-smtp-from", "", "Sender address of notification mails")
+	flag.StringVar(&notifySMTPTo, "notify-smtp-to", "", "Comma separated recipients of notification mails")
+	flag.StringVar(&notifySMTPUsername, "notify-smtp-username", "", "SMTP username, the password is read from the NOTIFY_SMTP_PASSWORD environment variable")
 	flag.Parse()
 	if loglevel == "" {
 		loglevel = "info"
@@ -65,40 +208,173 @@ func init() {
 }
 
 func main() {
+	var customGVRs []schema.GroupVersionResource
+	for _, check := range customResources {
+		customGVRs = append(customGVRs, check.GVR)
+	}
+	var optionalCheckers []string
+	if checkCluster {
+		optionalCheckers = append(optionalCheckers, "cluster")
+	}
+	if runAudit {
+		optionalCheckers = append(optionalCheckers, "audit")
+	}
+	if probeTransport == "auto" {
+		probeTransport = "direct"
+		if kubeconfig != "" {
+			probeTransport = "portforward"
+		}
+	}
+	if verifyProbes {
+		optionalCheckers = append(optionalCheckers, "probe", probeTransport)
+	}
+	var execAssertions []pod.ExecAssertion
+	if execAssertionsFile != "" {
+		assertions, err := pod.LoadExecAssertions(execAssertionsFile)
+		if err != nil {
+			logger.AppLog.LogFatal("cannot load exec assertions. reason: %v\n", err)
+		}
+		execAssertions = assertions
+	}
+	var imagePolicy *pod.ImagePolicy
+	if disallowLatestTag || allowedRegistries != "" || requireImageDigest || len(expectedDigests) > 0 {
+		imagePolicy = &pod.ImagePolicy{
+			DisallowLatest:  disallowLatestTag,
+			RequireDigest:   requireImageDigest,
+			ExpectedDigests: expectedDigests,
+		}
+		if allowedRegistries != "" {
+			registries, err := splitList(allowedRegistries)
+			if err != nil {
+				logger.AppLog.LogFatal("invalid --allowed-registries. reason: %v\n", err)
+			}
+			imagePolicy.AllowedRegistries = registries
+		}
+		optionalCheckers = append(optionalCheckers, "image")
+	}
+	rules := preflight.Rules(customGVRs, optionalCheckers...)
+	if len(execAssertions) > 0 {
+		var assertionNamespaces []string
+		for _, assertion := range execAssertions {
+			assertionNamespaces = append(assertionNamespaces, assertion.Namespace)
+		}
+		rules = append(rules, preflight.ExecRules(assertionNamespaces)...)
+	}
+	if mode != "once" && mode != "daemon" && mode != "server" {
+		logger.AppLog.LogFatal("invalid mode %q. supported modes are once, daemon, server\n", mode)
+	}
+	var notifiers []notify.Notifier
+	if notifyWebhookURL != "" {
+		notifier, err := notify.NewWebhookNotifier(notifyWebhookURL, notifyWebhookTemplate)
+		if err != nil {
+			logger.AppLog.LogFatal("cannot load webhook template. reason: %v\n", err)
+		}
+		notifiers = append(notifiers, notifier)
+	}
+	if notifySlackURL != "" {
+		notifiers = append(notifiers, notify.NewSlackNotifier(notifySlackURL))
+	}
+	if notifySMTPAddress != "" {
+		if notifySMTPFrom == "" || notifySMTPTo == "" {
+			logger.AppLog.LogFatal("--notify-smtp-address needs --notify-smtp-from and --notify-smtp-to\n")
+		}
+		recipients, err := splitList(notifySMTPTo)
+		if err != nil {
+			logger.AppLog.LogFatal("invalid --notify-smtp-to. reason: %v\n", err)
+		}
+		notifiers = append(notifiers, notify.NewSMTPNotifier(notifySMTPAddress, notifySMTPFrom, recipients, notifySMTPUsername, os.Getenv("NOTIFY_SMTP_PASSWORD")))
+	}
+	triggers, err := notify.ParseTriggers(notifyOn)
+	if err != nil {
+		logger.AppLog.LogFatal("invalid --notify-on. reason: %v\n", err)
+	}
+	if generateRBAC {
+		subject := rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Namespace: strings.Split(namespace, ",")[0], Name: "integration-test"}
+		if serviceAccount != "" {
+			saNamespace, saName, found := strings.Cut(serviceAccount, "/")
+			if !found || saNamespace == "" || saName == "" {
+				logger.AppLog.LogFatal("invalid service account %q, expected <namespace>/<name>\n", serviceAccount)
+			}
+			subject.Namespace, subject.Name = saNamespace, saName
+		}
+		manifest, err := preflight.GenerateRBAC("integration-test", subject, strings.Split(namespace, ","), rules)
+		if err != nil {
+			logger.AppLog.LogFatal("cannot generate rbac. reason: %v\n", err)
+		}
+		fmt.Print(string(manifest))
+		return
+	}
 	cfg := &Config{
 		NsList:     strings.Split(namespace, ","),
 		ClientSet:  client.GetClient(kubeconfig),
+		Dynamic:    client.GetDynamicClient(kubeconfig),
 		KubeConfig: kubeconfig,
 		LogLevel:   loglevel,
 		Interval:   interval,
 		Timeout:    timeout,
+
+		ExecAssertions: execAssertions,
+		ImagePolicy:    imagePolicy,
 	}
-	logger.AppLog.LogStartup(cfg.NsList, cfg.ClientSet, cfg.KubeConfig, cfg.LogLevel, cfg.Interval, cfg.Timeout)
-	err := deployment.CheckDeployments(cfg.NsList, cfg.ClientSet, interval, timeout)
-	if err != nil {
-		logger.AppLog.LogError("cannot validate deployements. reason: %v\n", err)
-		errList = append(errList, err)
+	if len(notifiers) > 0 {
+		cfg.Notifier = notify.NewDispatcher(notifiers, triggers, defaultNotifyTimeout)
 	}
-	err = statefulset.CheckStatefulSets(cfg.NsList, cfg.ClientSet, interval, timeout)
-	if err != nil {
-		logger.AppLog.LogError("cannot validate statefulsets. reason: %v\n", err)
-		errList = append(errList, err)
+	if len(execAssertions) > 0 {
+		cfg.Executor = pod.NewRemoteExecutor(client.GetConfig(kubeconfig), cfg.ClientSet)
 	}
-	err = service.CheckServices(cfg.NsList, cfg.ClientSet, interval, timeout)
-	if err != nil {
-		logger.AppLog.LogError("cannot validate services. reason: %v\n", err)
-		errList = append(errList, err)
+	logger.AppLog.LogStartup(cfg.NsList, cfg.ClientSet, cfg.KubeConfig, cfg.LogLevel, cfg.Interval, cfg.Timeout)
+	switch probeTransport {
+	case "proxy":
+		cfg.Transport = probe.NewProxyTransport(cfg.ClientSet)
+	case "direct":
+		cfg.Transport = probe.NewDirectTransport()
+	case "portforward":
+		cfg.Transport = probe.NewPortForwardTransport(client.GetConfig(kubeconfig), cfg.ClientSet)
+	default:
+		logger.AppLog.LogFatal("invalid probe transport %q. supported transports are auto, proxy, direct, portforward\n", probeTransport)
 	}
-	err = replicaset.CheckReplicaSets(cfg.NsList, cfg.ClientSet, interval, timeout)
-	if err != nil {
-		logger.AppLog.LogError("cannot validate replicasets. reason: %v\n", err)
-		errList = append(errList, err)
+	if runPreflight {
+		if err := preflight.CheckPermissions(cfg.NsList, rules, cfg.ClientSet); err != nil {
+			logger.AppLog.LogFatal("rbac preflight failed. reason: %v\n", err)
+		}
 	}
-	err = daemonset.CheckDaemonSets(cfg.NsList, cfg.ClientSet, interval, timeout)
-	if err != nil {
-		logger.AppLog.LogError("cannot validate daemonsets. reason: %v\n", err)
-		errList = append(errList, err)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if mode == "server" {
+		store := api.NewMemoryStore()
+		if runsDir != "" {
+			var err error
+			if store, err = api.NewFileStore(runsDir); err != nil {
+				logger.AppLog.LogFatal("cannot open run history. reason: %v\n", err)
+			}
+		}
+		server := api.NewServer(store, suiteRunner{cfg: cfg}, cfg.NsList)
+		mux := http.NewServeMux()
+		mux.Handle("/runs", server.Handler())
+		mux.Handle("/runs/", server.Handler())
+		mux.Handle("/metrics", metrics.Handler())
+		if err := server.Serve(ctx, listenAddress, mux); err != nil {
+			logger.AppLog.LogFatal("server stopped. reason: %v\n", err)
+		}
+		return
+	}
+	checks := newChecks(cfg, cfg.NsList)
+	if mode == "daemon" {
+		err := daemon.New(listenAddress, schedule, func() error {
+			errs := runChecks(checks)
+			notifyRun(cfg, cfg.NsList, nil, errs)
+			if len(errs) > 0 {
+				return fmt.Errorf("%d checks failed", len(errs))
+			}
+			return nil
+		}).Run(ctx)
+		if err != nil {
+			logger.AppLog.LogFatal("daemon stopped. reason: %v\n", err)
+		}
+		return
 	}
+	errList = runChecks(checks)
+	notifyRun(cfg, cfg.NsList, nil, errList)
 	if len(errList) > 0 {
 		//TODO: Print out the list of errors
 		logger.AppLog.LogFatal("integration-tests failed. See the above list of errors")
@@ -4,6 +4,9 @@ local defaults = {
     namespace: error 'must provide namespace',
     roleBindingName: error 'must provide rolebinding name',
     serviceAccountName: error 'must provide service account name',
+    // opt-in grants for --exec-assertions and --probe-transport=portforward
+    enableExec: false,
+    enablePortForward: false,
 
     labels::{
         'app.kubernetes.io/component': 'observability',
@@ -32,8 +35,55 @@ function(params){
         },
         rules:[
             {
-                apiGroups: ['','apps'],
-                resources:['deployments','statefulsets','services','endpoints','pods','namespaces','pods/log','daemonsets','replicasets'],
+                apiGroups: ['','apps','discovery.k8s.io'],
+                resources:['deployments','statefulsets','services','endpoints','pods','namespaces','pods/log','daemonsets','replicasets','persistentvolumeclaims','endpointslices'],
+                verbs:['get','list','watch'],
+            },
+            {
+                apiGroups: ['networking.k8s.io','route.openshift.io','gateway.networking.k8s.io','autoscaling','policy'],
+                resources:['ingresses','routes','gateways','httproutes','horizontalpodautoscalers','poddisruptionbudgets'],
+                verbs:['get','list','watch'],
+            },
+            {
+                apiGroups: [''],
+                resources:['secrets','configmaps'],
+                verbs:['get','list'],
+            },
+            {
+                apiGroups: [''],
+                resources:['pods/proxy'],
+                verbs:['get'],
+            },
+            {
+                apiGroups: ['operators.coreos.com','argoproj.io'],
+                resources:['subscriptions','clusterserviceversions','installplans','applications'],
+                verbs:['get','list','watch'],
+            },
+        ] + (if rbac.config.enableExec then [
+            {
+                apiGroups: [''],
+                resources:['pods/exec'],
+                verbs:['create'],
+            },
+        ] else []) + (if rbac.config.enablePortForward then [
+            {
+                apiGroups: [''],
+                resources:['pods/portforward'],
+                verbs:['create'],
+            },
+        ] else []),
+    },
+    clusterRole:{
+        apiVersion: 'rbac.authorization.k8s.io/v1',
+        kind: 'ClusterRole',
+        metadata:{
+            labels: rbac.config.labels,
+            name: rbac.config.roleName,
+        },
+        rules:[
+            {
+                apiGroups: [''],
+                resources:['nodes'],
                 verbs:['get','list','watch'],
             },
         ],
@@ -59,4 +109,24 @@ function(params){
             },
         ],
     },
+    clusterRoleBinding:{
+        apiVersion: 'rbac.authorization.k8s.io/v1',
+        kind: 'ClusterRoleBinding',
+        metadata: {
+            labels: rbac.config.labels,
+            name: rbac.config.roleBindingName,
+        },
+        roleRef:{
+            apiGroup: 'rbac.authorization.k8s.io',
+            kind: 'ClusterRole',
+            name: rbac.clusterRole.metadata.name,
+        },
+        subjects:[
+            {
+                kind: 'ServiceAccount',
+                name: rbac.serviceAccount.metadata.name,
+                namespace: rbac.serviceAccount.metadata.namespace
+            },
+        ],
+    },
 }
\ No newline at end of file
@@ -0,0 +1,289 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vprashar2929/integration-test/pkg/logger"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	shutdownTimeout = 10 * time.Second
+	// maxQueuedRuns caps the runs accepted but not finished, each can take minutes
+	maxQueuedRuns = 10
+	// maxRequestBytes caps the body of a run request, a request only lists namespaces and checker names
+	maxRequestBytes = 64 << 10
+)
+
+// Run statuses
+const (
+	StatusPending = "pending"
+	StatusRunning = "running"
+	StatusPassed  = "passed"
+	StatusFailed  = "failed"
+)
+
+var (
+	ErrNoNamespace         = errors.New("no namespace provided")
+	ErrInvalidNamespace    = errors.New("invalid namespace")
+	ErrNamespaceNotAllowed = errors.New("namespace is not monitored")
+	ErrTooManyRuns         = errors.New("too many runs queued, retry later")
+	ErrShuttingDown        = errors.New("server is shutting down")
+)
+
+// Request starts a run of the suite against the namespaces, an empty suite runs every enabled checker
+type Request struct {
+	Namespaces []string `json:"namespaces"`
+	Suite      []string `json:"suite,omitempty"`
+}
+
+// CheckResult is the outcome of a single checker of a run
+type CheckResult struct {
+	Checker  string  `json:"checker"`
+	Passed   bool    `json:"passed"`
+	Error    string  `json:"error,omitempty"`
+	Duration float64 `json:"durationSeconds"`
+}
+
+// Run is a requested run along with its status and results once finished
+type Run struct {
+	ID         string        `json:"id"`
+	Status     string        `json:"status"`
+	Request    Request       `json:"request"`
+	CreatedAt  time.Time     `json:"createdAt"`
+	StartedAt  *time.Time    `json:"startedAt,omitempty"`
+	FinishedAt *time.Time    `json:"finishedAt,omitempty"`
+	Results    []CheckResult `json:"results,omitempty"`
+	// Error explains a run that failed without running its checkers to the end
+	Error string `json:"error,omitempty"`
+}
+
+// Runner runs the checkers of a request
+type Runner interface {
+	// Validate rejects requests naming unknown checkers before the run is accepted
+	Validate(request Request) error
+	// Run stops starting checkers once ctx is done
+	Run(ctx context.Context, request Request) []CheckResult
+}
+
+// Server accepts runs over HTTP and executes them one at a time in the background
+type Server struct {
+	store  Store
+	runner Runner
+	// namespaces bounds what a request may check, the permissions of the tool are only granted there
+	namespaces map[string]bool
+	// mu serializes runs, checkers wait on the cluster and are not meant to run side by side
+	mu sync.Mutex
+	wg sync.WaitGroup
+	// queued holds a slot for every run accepted and not finished
+	queued chan struct{}
+	// ctx is cancelled on shutdown so queued runs do not start and the running one stops early
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewServer returns a server keeping runs in store that accepts runs against the given namespaces only
+func NewServer(store Store, runner Runner, namespaces []string) *Server {
+	allowed := make(map[string]bool)
+	for _, namespace := range namespaces {
+		allowed[namespace] = true
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Server{store: store, runner: runner, namespaces: allowed, queued: make(chan struct{}, maxQueuedRuns), ctx: ctx, cancel: cancel}
+}
+
+// validateNamespaces rejects namespaces that are not DNS labels or not monitored
+func (s *Server) validateNamespaces(namespaces []string) error {
+	if len(namespaces) == 0 {
+		return ErrNoNamespace
+	}
+	for _, namespace := range namespaces {
+		if errs := validation.IsDNS1123Label(namespace); len(errs) > 0 {
+			return fmt.Errorf("%w: %q: %s", ErrInvalidNamespace, namespace, strings.Join(errs, ", "))
+		}
+		if !s.namespaces[namespace] {
+			return fmt.Errorf("%w: %s", ErrNamespaceNotAllowed, namespace)
+		}
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logger.AppLog.LogError("cannot write response. reason: %v\n", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, map[string]string{"error": err.Error()})
+}
+
+// Handler serves POST /runs, GET /runs and GET /runs/{id}
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/runs", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			s.createRun(w, r)
+		case http.MethodGet:
+			s.listRuns(w)
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		}
+	})
+	mux.HandleFunc("/runs/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", "GET")
+			writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+			return
+		}
+		s.getRun(w, strings.TrimPrefix(r.URL.Path, "/runs/"))
+	})
+	return mux
+}
+
+func (s *Server) createRun(w http.ResponseWriter, r *http.Request) {
+	var request Request
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&request); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			writeError(w, http.StatusRequestEntityTooLarge, err)
+			return
+		}
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := s.validateNamespaces(request.Namespaces); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := s.runner.Validate(request); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if s.ctx.Err() != nil {
+		writeError(w, http.StatusServiceUnavailable, ErrShuttingDown)
+		return
+	}
+	select {
+	case s.queued <- struct{}{}:
+	default:
+		w.Header().Set("Retry-After", "60")
+		writeError(w, http.StatusTooManyRequests, ErrTooManyRuns)
+		return
+	}
+	run := Run{ID: uuid.NewString(), Status: StatusPending, Request: request, CreatedAt: time.Now().UTC()}
+	if err := s.store.Save(run); err != nil {
+		<-s.queued
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	s.wg.Add(1)
+	go s.execute(run)
+	w.Header().Set("Location", "/runs/"+run.ID)
+	writeJSON(w, http.StatusAccepted, run)
+}
+
+func (s *Server) save(run Run) {
+	if err := s.store.Save(run); err != nil {
+		logger.AppLog.LogError("cannot save run %s. reason: %v\n", run.ID, err)
+	}
+}
+
+func (s *Server) execute(run Run) {
+	defer s.wg.Done()
+	defer func() { <-s.queued }()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ctx.Err() != nil {
+		finished := time.Now().UTC()
+		run.Status, run.FinishedAt, run.Error = StatusFailed, &finished, "cancelled by shutdown before it started"
+		s.save(run)
+		return
+	}
+	started := time.Now().UTC()
+	run.Status, run.StartedAt = StatusRunning, &started
+	s.save(run)
+	logger.AppLog.LogInfo("starting run %s for namespaces %v\n", run.ID, run.Request.Namespaces)
+
+	run.Results = s.runner.Run(s.ctx, run.Request)
+	finished := time.Now().UTC()
+	run.Status, run.FinishedAt = StatusPassed, &finished
+	for _, result := range run.Results {
+		if !result.Passed {
+			run.Status = StatusFailed
+		}
+	}
+	if s.ctx.Err() != nil {
+		run.Status, run.Error = StatusFailed, "cancelled by shutdown"
+	}
+	s.save(run)
+	logger.AppLog.LogInfo("run %s %s\n", run.ID, run.Status)
+}
+
+func (s *Server) listRuns(w http.ResponseWriter) {
+	runs, err := s.store.List()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, runs)
+}
+
+func (s *Server) getRun(w http.ResponseWriter, id string) {
+	run, err := s.store.Get(id)
+	if errors.Is(err, ErrRunNotFound) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, run)
+}
+
+// Serve serves handler on addr until ctx is done. Queued runs are then cancelled and the running one gets
+// shutdownTimeout to stop, a run cut short is marked failed by the file store on the next start.
+func (s *Server) Serve(ctx context.Context, addr string, handler http.Handler) error {
+	server := &http.Server{Addr: addr, Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.ListenAndServe()
+	}()
+	logger.AppLog.LogInfo("serving run api on %s\n", addr)
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+	s.cancel()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	err := server.Shutdown(shutdownCtx)
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-shutdownCtx.Done():
+		logger.AppLog.LogWarning("stopping with a run in progress\n")
+	}
+	return err
+}
@@ -0,0 +1,201 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vprashar2929/integration-test/pkg/logger"
+)
+
+var testNamespaces = []string{"test-namespace", "ns"}
+
+// fakeRunner fails the checkers named in failing and knows only deployment and service, when block is set
+// Run waits on it or on ctx
+type fakeRunner struct {
+	failing map[string]bool
+	block   chan struct{}
+}
+
+func (f *fakeRunner) Validate(request Request) error {
+	for _, checker := range request.Suite {
+		if checker != "deployment" && checker != "service" {
+			return errors.New("unknown checker " + checker)
+		}
+	}
+	return nil
+}
+
+func (f *fakeRunner) Run(ctx context.Context, request Request) []CheckResult {
+	if f.block != nil {
+		select {
+		case <-f.block:
+		case <-ctx.Done():
+			return []CheckResult{{Checker: "deployment", Error: ctx.Err().Error()}}
+		}
+	}
+	suite := request.Suite
+	if len(suite) == 0 {
+		suite = []string{"deployment", "service"}
+	}
+	var results []CheckResult
+	for _, checker := range suite {
+		result := CheckResult{Checker: checker, Passed: !f.failing[checker]}
+		if !result.Passed {
+			result.Error = checker + " not ready"
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+func postRun(t *testing.T, url, body string) (*http.Response, Run) {
+	resp, err := http.Post(url+"/runs", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	defer resp.Body.Close()
+	var run Run
+	json.NewDecoder(resp.Body).Decode(&run)
+	return resp, run
+}
+
+// waitForRun polls the run until it finished
+func waitForRun(t *testing.T, url, id string) Run {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		resp, err := http.Get(url + "/runs/" + id)
+		if err != nil {
+			t.Fatalf("expected nil, got: %v", err)
+		}
+		var run Run
+		json.NewDecoder(resp.Body).Decode(&run)
+		resp.Body.Close()
+		if run.Status == StatusPassed || run.Status == StatusFailed {
+			return run
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("expected run %s to finish", id)
+	return Run{}
+}
+
+func TestRuns(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	server := NewServer(NewMemoryStore(), &fakeRunner{failing: map[string]bool{"service": true}}, testNamespaces)
+	srv := httptest.NewServer(server.Handler())
+	defer srv.Close()
+
+	resp, run := postRun(t, srv.URL, `{"namespaces":["test-namespace"],"suite":["deployment"]}`)
+	if resp.StatusCode != http.StatusAccepted || run.ID == "" {
+		t.Fatalf("expected accepted run, got: %d %+v", resp.StatusCode, run)
+	}
+	if location := resp.Header.Get("Location"); location != "/runs/"+run.ID {
+		t.Fatalf("expected location of the run, got: %s", location)
+	}
+	run = waitForRun(t, srv.URL, run.ID)
+	if run.Status != StatusPassed || len(run.Results) != 1 || run.FinishedAt == nil {
+		t.Fatalf("expected passed run with one result, got: %+v", run)
+	}
+
+	_, run = postRun(t, srv.URL, `{"namespaces":["test-namespace"]}`)
+	run = waitForRun(t, srv.URL, run.ID)
+	if run.Status != StatusFailed || run.Results[1].Error != "service not ready" {
+		t.Fatalf("expected failed service check, got: %+v", run)
+	}
+
+	resp, err := http.Get(srv.URL + "/runs")
+	if err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	defer resp.Body.Close()
+	var runs []Run
+	json.NewDecoder(resp.Body).Decode(&runs)
+	if len(runs) != 2 || runs[0].ID != run.ID {
+		t.Fatalf("expected 2 runs, latest first, got: %+v", runs)
+	}
+}
+
+func TestCreateRunInvalid(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	srv := httptest.NewServer(NewServer(NewMemoryStore(), &fakeRunner{}, testNamespaces).Handler())
+	defer srv.Close()
+	for _, body := range []string{`{"namespaces":[]}`, `{"namespaces":["kube-system"]}`, `{"namespaces":["ns\r\nBcc: x"]}`, `{"namespaces":["ns"],"suite":["unknown"]}`, `{"namespace":"ns"}`, `not json`} {
+		if resp, _ := postRun(t, srv.URL, body); resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("expected 400 for %s, got: %d", body, resp.StatusCode)
+		}
+	}
+}
+
+func TestCreateRunTooLarge(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	srv := httptest.NewServer(NewServer(NewMemoryStore(), &fakeRunner{}, testNamespaces).Handler())
+	defer srv.Close()
+	body := `{"namespaces":["ns"],"suite":["` + strings.Repeat("x", maxRequestBytes) + `"]}`
+	if resp, _ := postRun(t, srv.URL, body); resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413, got: %d", resp.StatusCode)
+	}
+}
+
+func TestGetRunNotFound(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	srv := httptest.NewServer(NewServer(NewMemoryStore(), &fakeRunner{}, testNamespaces).Handler())
+	defer srv.Close()
+	resp, err := http.Get(srv.URL + "/runs/missing")
+	if err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404, got: %d", resp.StatusCode)
+	}
+}
+
+func TestCreateRunQueueFull(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	runner := &fakeRunner{block: make(chan struct{})}
+	server := NewServer(NewMemoryStore(), runner, testNamespaces)
+	srv := httptest.NewServer(server.Handler())
+	defer srv.Close()
+	defer server.wg.Wait()
+	defer close(runner.block)
+	for i := 0; i < maxQueuedRuns; i++ {
+		if resp, _ := postRun(t, srv.URL, `{"namespaces":["ns"]}`); resp.StatusCode != http.StatusAccepted {
+			t.Fatalf("expected 202, got: %d", resp.StatusCode)
+		}
+	}
+	if resp, _ := postRun(t, srv.URL, `{"namespaces":["ns"]}`); resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got: %d", resp.StatusCode)
+	}
+}
+
+func TestServeCancelsRuns(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	store := NewMemoryStore()
+	server := NewServer(store, &fakeRunner{block: make(chan struct{})}, testNamespaces)
+	srv := httptest.NewServer(server.Handler())
+	_, running := postRun(t, srv.URL, `{"namespaces":["ns"]}`)
+	_, queued := postRun(t, srv.URL, `{"namespaces":["ns"]}`)
+	srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start := time.Now()
+	if err := server.Serve(ctx, "127.0.0.1:0", server.Handler()); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	if time.Since(start) > shutdownTimeout {
+		t.Fatalf("expected shutdown to cancel the runs, took %v", time.Since(start))
+	}
+	for _, id := range []string{running.ID, queued.ID} {
+		run, err := store.Get(id)
+		if err != nil || run.Status != StatusFailed || run.Error == "" {
+			t.Fatalf("expected cancelled run to fail, got: %+v %v", run, err)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Store keeps the history of runs
+type Store interface {
+	Save(run Run) error
+	Get(id string) (Run, error)
+	List() ([]Run, error)
+}
+
+var ErrRunNotFound = errors.New("run not found")
+
+// maxStoredRuns caps the history, the oldest finished runs are dropped first
+var maxStoredRuns = 1000
+
+// sortRuns orders runs from the most recently created
+func sortRuns(runs []Run) {
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i].CreatedAt.After(runs[j].CreatedAt)
+	})
+}
+
+func isFinished(run Run) bool {
+	return run.Status == StatusPassed || run.Status == StatusFailed
+}
+
+// expiredRuns returns the finished runs beyond maxStoredRuns of runs sorted from the most recent
+func expiredRuns(runs []Run) []Run {
+	if len(runs) <= maxStoredRuns {
+		return nil
+	}
+	var expired []Run
+	for _, run := range runs[maxStoredRuns:] {
+		if isFinished(run) {
+			expired = append(expired, run)
+		}
+	}
+	return expired
+}
+
+// memoryStore loses the history on restart
+type memoryStore struct {
+	mu   sync.RWMutex
+	runs map[string]Run
+}
+
+// NewMemoryStore returns a store keeping runs in memory
+func NewMemoryStore() Store {
+	return &memoryStore{runs: make(map[string]Run)}
+}
+
+func (m *memoryStore) Save(run Run) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.runs[run.ID] = run
+	if isFinished(run) && len(m.runs) > maxStoredRuns {
+		runs := make([]Run, 0, len(m.runs))
+		for _, stored := range m.runs {
+			runs = append(runs, stored)
+		}
+		sortRuns(runs)
+		for _, expired := range expiredRuns(runs) {
+			delete(m.runs, expired.ID)
+		}
+	}
+	return nil
+}
+
+func (m *memoryStore) Get(id string) (Run, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	run, ok := m.runs[id]
+	if !ok {
+		return Run{}, ErrRunNotFound
+	}
+	return run, nil
+}
+
+func (m *memoryStore) List() ([]Run, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	runs := make([]Run, 0, len(m.runs))
+	for _, run := range m.runs {
+		runs = append(runs, run)
+	}
+	sortRuns(runs)
+	return runs, nil
+}
+
+// fileStore writes every run as <id>.json into a directory
+type fileStore struct {
+	mu  sync.RWMutex
+	dir string
+}
+
+// NewFileStore returns a store keeping runs as JSON files in dir, creating it when missing. Runs left pending
+// or running by a previous process will never finish and are marked failed.
+func NewFileStore(dir string) (Store, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+	store := &fileStore{dir: dir}
+	runs, err := store.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, run := range runs {
+		if run.Status != StatusPending && run.Status != StatusRunning {
+			continue
+		}
+		finished := time.Now().UTC()
+		run.Status, run.FinishedAt, run.Error = StatusFailed, &finished, "interrupted by a restart"
+		if err = store.Save(run); err != nil {
+			return nil, err
+		}
+	}
+	return store, nil
+}
+
+func (f *fileStore) path(id string) string {
+	return filepath.Join(f.dir, id+".json")
+}
+
+func (f *fileStore) Save(run Run) error {
+	data, err := json.MarshalIndent(run, "", "  ")
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	// write then rename so readers never see a partial file
+	tmp := f.path(run.ID) + ".tmp"
+	if err = os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp, f.path(run.ID)); err != nil || !isFinished(run) {
+		return err
+	}
+	runs, err := f.list()
+	if err != nil {
+		return err
+	}
+	for _, expired := range expiredRuns(runs) {
+		if err = os.Remove(f.path(expired.ID)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *fileStore) read(path string) (Run, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Run{}, err
+	}
+	var run Run
+	err = json.Unmarshal(data, &run)
+	return run, err
+}
+
+func (f *fileStore) Get(id string) (Run, error) {
+	// ids come from the url, only plain names may reach the filesystem
+	if id == "" || strings.ContainsAny(id, `/\.`) {
+		return Run{}, ErrRunNotFound
+	}
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	run, err := f.read(f.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return Run{}, ErrRunNotFound
+	}
+	return run, err
+}
+
+func (f *fileStore) List() ([]Run, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.list()
+}
+
+// list reads every run, callers hold mu
+func (f *fileStore) list() ([]Run, error) {
+	paths, err := filepath.Glob(filepath.Join(f.dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	runs := make([]Run, 0, len(paths))
+	for _, path := range paths {
+		run, err := f.read(path)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	sortRuns(runs)
+	return runs, nil
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFileStore(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	now := time.Now().UTC()
+	older := Run{ID: "older", Status: StatusPassed, CreatedAt: now.Add(-time.Minute)}
+	newer := Run{ID: "newer", Status: StatusPending, CreatedAt: now}
+	for _, run := range []Run{older, newer} {
+		if err = store.Save(run); err != nil {
+			t.Fatalf("expected nil, got: %v", err)
+		}
+	}
+	newer.Status = StatusRunning
+	if err = store.Save(newer); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	run, err := store.Get("newer")
+	if err != nil || run.Status != StatusRunning {
+		t.Fatalf("expected running run, got: %+v %v", run, err)
+	}
+	runs, err := store.List()
+	if err != nil || len(runs) != 2 || runs[0].ID != "newer" {
+		t.Fatalf("expected 2 runs, newer first, got: %+v %v", runs, err)
+	}
+	for _, id := range []string{"missing", "../older"} {
+		if _, err = store.Get(id); err != ErrRunNotFound {
+			t.Fatalf("expected ErrRunNotFound for %s, got: %v", id, err)
+		}
+	}
+}
+
+func TestStoresDropOldestFinishedRuns(t *testing.T) {
+	defer func(max int) { maxStoredRuns = max }(maxStoredRuns)
+	maxStoredRuns = 2
+	fileStore, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	now := time.Now().UTC()
+	for _, store := range []Store{NewMemoryStore(), fileStore} {
+		runs := []Run{
+			{ID: "pending", Status: StatusPending, CreatedAt: now.Add(-3 * time.Minute)},
+			{ID: "oldest", Status: StatusPassed, CreatedAt: now.Add(-2 * time.Minute)},
+			{ID: "older", Status: StatusFailed, CreatedAt: now.Add(-time.Minute)},
+			{ID: "newest", Status: StatusPassed, CreatedAt: now},
+		}
+		for _, run := range runs {
+			if err = store.Save(run); err != nil {
+				t.Fatalf("expected nil, got: %v", err)
+			}
+		}
+		stored, err := store.List()
+		if err != nil || len(stored) != 3 || stored[0].ID != "newest" || stored[1].ID != "older" || stored[2].ID != "pending" {
+			t.Fatalf("expected the oldest finished run to be dropped, got: %+v %v", stored, err)
+		}
+	}
+}
+
+func TestNewFileStoreFailsInterruptedRuns(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	for _, run := range []Run{{ID: "pending", Status: StatusPending}, {ID: "running", Status: StatusRunning}, {ID: "passed", Status: StatusPassed}} {
+		if err = store.Save(run); err != nil {
+			t.Fatalf("expected nil, got: %v", err)
+		}
+	}
+	if store, err = NewFileStore(dir); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	for id, status := range map[string]string{"pending": StatusFailed, "running": StatusFailed, "passed": StatusPassed} {
+		if run, err := store.Get(id); err != nil || run.Status != status {
+			t.Fatalf("expected %s to be %s, got: %+v %v", id, status, run, err)
+		}
+	}
+}
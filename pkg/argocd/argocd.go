@@ -0,0 +1,231 @@
+package argocd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/vprashar2929/integration-test/pkg/daemonset"
+	"github.com/vprashar2929/integration-test/pkg/deployment"
+	"github.com/vprashar2929/integration-test/pkg/logger"
+	"github.com/vprashar2929/integration-test/pkg/service"
+	"github.com/vprashar2929/integration-test/pkg/statefulset"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	syncStatusSynced    = "Synced"
+	healthStatusHealthy = "Healthy"
+	healthDegraded      = "Degraded"
+	// inClusterServer and inClusterName are how Argo CD addresses the cluster it runs in
+	inClusterServer = "https://kubernetes.default.svc"
+	inClusterName   = "in-cluster"
+)
+
+var ApplicationGVR = schema.GroupVersionResource{Group: "argoproj.io", Version: "v1alpha1", Resource: "applications"}
+
+var (
+	ErrListingApplication    = errors.New("error listing argocd applications in namespace")
+	ErrNoApplication         = errors.New("no argocd applications found in namespace")
+	ErrNoNamespace           = errors.New("no namespace provided")
+	ErrApplicationNotSynced  = errors.New("argocd application not synced")
+	ErrApplicationRevision   = errors.New("argocd application not synced to expected revision")
+	ErrApplicationNotHealthy = errors.New("argocd application not healthy")
+	ErrApplicationDegraded   = errors.New("argocd application has degraded resources")
+	ErrInvalidInterval       = errors.New("interval or timeout is invalid")
+	ErrDestinationNotHealthy = errors.New("argocd application destination not healthy")
+)
+
+func getApplication(namespace string, dynamicClient dynamic.Interface) (*unstructured.UnstructuredList, error) {
+	applications, err := dynamicClient.Resource(ApplicationGVR).Namespace(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		// Argo CD is not installed on the cluster
+		if apierrors.IsNotFound(err) {
+			return nil, ErrNoApplication
+		}
+		logger.AppLog.LogError("error listing argocd applications in namespace %s: %v\n", namespace, err)
+		return nil, ErrListingApplication
+	}
+	if len(applications.Items) == 0 {
+		return nil, ErrNoApplication
+	}
+	return applications, nil
+}
+
+func storeApplicationsByNamespace(namespaces []string, dynamicClient dynamic.Interface) (map[string][]unstructured.Unstructured, error) {
+	if len(namespaces) == 0 {
+		return nil, ErrNoNamespace
+	}
+	applicationsByNamespace := make(map[string][]unstructured.Unstructured)
+	for _, namespace := range namespaces {
+		if namespace == "" {
+			logger.AppLog.LogError("Invalid namespace provided.")
+			continue
+		}
+		applicationList, err := getApplication(namespace, dynamicClient)
+		if err != nil {
+			if errors.Is(err, ErrNoApplication) {
+				continue
+			}
+			return nil, err
+		}
+		applicationsByNamespace[namespace] = applicationList.Items
+	}
+	if len(applicationsByNamespace) == 0 {
+		return nil, ErrNoApplication
+	}
+	return applicationsByNamespace, nil
+}
+
+// syncedRevisions returns the revision of a single source application or every revision of a multi source one
+func syncedRevisions(application *unstructured.Unstructured) []string {
+	if revision, _, _ := unstructured.NestedString(application.Object, "status", "sync", "revision"); revision != "" {
+		return []string{revision}
+	}
+	revisions, _, _ := unstructured.NestedStringSlice(application.Object, "status", "sync", "revisions")
+	return revisions
+}
+
+// getDegradedResources returns kind/namespace/name of every managed resource reported as Degraded
+func getDegradedResources(application *unstructured.Unstructured) []string {
+	var degraded []string
+	resources, _, _ := unstructured.NestedSlice(application.Object, "status", "resources")
+	for _, resource := range resources {
+		resourceMap, ok := resource.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if health, _, _ := unstructured.NestedString(resourceMap, "health", "status"); health != healthDegraded {
+			continue
+		}
+		kind, _ := resourceMap["kind"].(string)
+		namespace, _ := resourceMap["namespace"].(string)
+		name, _ := resourceMap["name"].(string)
+		degraded = append(degraded, kind+"/"+namespace+"/"+name)
+	}
+	return degraded
+}
+
+// isInCluster reports whether the application deploys to the cluster Argo CD runs in, the only one the
+// clientset can reach
+func isInCluster(application *unstructured.Unstructured) bool {
+	server, _, _ := unstructured.NestedString(application.Object, "spec", "destination", "server")
+	name, _, _ := unstructured.NestedString(application.Object, "spec", "destination", "name")
+	return server == inClusterServer || name == inClusterName
+}
+
+// checkApplicationStatus returns the destination namespace of the application once it is synced and healthy,
+// or an empty namespace when the application deploys to a remote cluster
+func checkApplicationStatus(namespace string, application unstructured.Unstructured, revision string, dynamicClient dynamic.Interface) (string, error) {
+	updatedApplication, err := dynamicClient.Resource(ApplicationGVR).Namespace(namespace).Get(context.TODO(), application.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	syncStatus, _, _ := unstructured.NestedString(updatedApplication.Object, "status", "sync", "status")
+	if syncStatus != syncStatusSynced {
+		return "", fmt.Errorf("%w: sync status is %q", ErrApplicationNotSynced, syncStatus)
+	}
+	if revision != "" {
+		revisions := syncedRevisions(updatedApplication)
+		matched := false
+		for _, synced := range revisions {
+			if synced == revision {
+				matched = true
+			}
+		}
+		if !matched {
+			return "", fmt.Errorf("%w: synced %s, expected %s", ErrApplicationRevision, strings.Join(revisions, ","), revision)
+		}
+	}
+	if degraded := getDegradedResources(updatedApplication); len(degraded) > 0 {
+		return "", fmt.Errorf("%w: %s", ErrApplicationDegraded, strings.Join(degraded, ", "))
+	}
+	healthStatus, _, _ := unstructured.NestedString(updatedApplication.Object, "status", "health", "status")
+	if healthStatus != healthStatusHealthy {
+		message, _, _ := unstructured.NestedString(updatedApplication.Object, "status", "health", "message")
+		return "", fmt.Errorf("%w: health status is %q %s", ErrApplicationNotHealthy, healthStatus, message)
+	}
+	logger.AppLog.LogInfo("argocd application %s is synced and healthy in namespace %s\n", application.GetName(), namespace)
+	if !isInCluster(updatedApplication) {
+		logger.AppLog.LogWarning("argocd application %s deploys to a remote cluster, skipping its destination checks\n", application.GetName())
+		return "", nil
+	}
+	destination, _, _ := unstructured.NestedString(updatedApplication.Object, "spec", "destination", "namespace")
+	return destination, nil
+}
+
+// checkDestination runs the native workload checks against the namespace an application deploys to
+func checkDestination(namespace string, clientset kubernetes.Interface, interval, timeout time.Duration) error {
+	namespaces := []string{namespace}
+	if err := deployment.CheckDeployments(namespaces, clientset, interval, timeout); err != nil {
+		return err
+	}
+	if err := statefulset.CheckStatefulSets(namespaces, clientset, interval, timeout); err != nil {
+		return err
+	}
+	if err := daemonset.CheckDaemonSets(namespaces, clientset, interval, timeout); err != nil {
+		return err
+	}
+	return service.CheckServices(namespaces, clientset, interval, timeout)
+}
+
+func validateApplicationsByNamespace(namespaces []string, applicationsByNamespace map[string][]unstructured.Unstructured, revision string, checkDestinations bool, dynamicClient dynamic.Interface, clientset kubernetes.Interface, interval, timeout time.Duration) error {
+	var err error
+	if interval <= 0 || timeout <= 0 {
+		return ErrInvalidInterval
+	}
+	checked := make(map[string]bool)
+	for _, namespace := range namespaces {
+		for _, application := range applicationsByNamespace[namespace] {
+
+			// check application sync and health status
+			var destination string
+			deadline := time.Now().Add(timeout)
+			for time.Now().Before(deadline) {
+				if destination, err = checkApplicationStatus(namespace, application, revision, dynamicClient); err == nil {
+					break
+				}
+				time.Sleep(interval)
+			}
+			if time.Now().After(deadline) {
+				return fmt.Errorf("timeout checking argocd application status for %s in namespace %s, error: %v", application.GetName(), namespace, err)
+			}
+
+			// check workloads in the destination namespace, once per namespace
+			if !checkDestinations || destination == "" || checked[destination] {
+				continue
+			}
+			checked[destination] = true
+			if err = checkDestination(destination, clientset, interval, timeout); err != nil {
+				return fmt.Errorf("%w: application %s namespace %s: %v", ErrDestinationNotHealthy, application.GetName(), destination, err)
+			}
+		}
+	}
+	return nil
+}
+
+func CheckApplications(namespaces []string, revision string, checkDestinations bool, dynamicClient dynamic.Interface, clientset kubernetes.Interface, interval, timeout time.Duration) error {
+	logger.AppLog.LogInfo("Begin Argo CD Application validation")
+
+	applicationsByNamespace, err := storeApplicationsByNamespace(namespaces, dynamicClient)
+	if err != nil {
+		if errors.Is(err, ErrNoApplication) {
+			logger.AppLog.LogWarning("No argocd applications found. Skipping validations.")
+			return nil
+		}
+		return err
+	}
+	if err = validateApplicationsByNamespace(namespaces, applicationsByNamespace, revision, checkDestinations, dynamicClient, clientset, interval, timeout); err != nil {
+		return err
+	}
+
+	logger.AppLog.LogInfo("End Argo CD Application validation")
+	return nil
+}
@@ -0,0 +1,146 @@
+package argocd
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vprashar2929/integration-test/pkg/logger"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var (
+	testNS          = "argocd"
+	testApp         = "test-application"
+	testDestination = "test-namespace"
+	testRevision    = "0f3c2a1b"
+)
+
+func newTestApplication(sync, health string, resources ...interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "argoproj.io/v1alpha1",
+			"kind":       "Application",
+			"metadata": map[string]interface{}{
+				"name":      testApp,
+				"namespace": testNS,
+			},
+			"spec": map[string]interface{}{
+				"destination": map[string]interface{}{"server": inClusterServer, "namespace": testDestination},
+			},
+			"status": map[string]interface{}{
+				"sync":      map[string]interface{}{"status": sync, "revision": testRevision},
+				"health":    map[string]interface{}{"status": health},
+				"resources": resources,
+			},
+		},
+	}
+}
+
+func newTestResource(kind, name, health string) map[string]interface{} {
+	return map[string]interface{}{
+		"kind":      kind,
+		"namespace": testDestination,
+		"name":      name,
+		"health":    map[string]interface{}{"status": health},
+	}
+}
+
+func newTestDynamicClient(objects ...runtime.Object) *dynamicfake.FakeDynamicClient {
+	return dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{ApplicationGVR: "ApplicationList"}, objects...)
+}
+
+func TestCheckApplicationStatus(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	application := newTestApplication(syncStatusSynced, healthStatusHealthy, newTestResource("Deployment", "app", healthStatusHealthy))
+	destination, err := checkApplicationStatus(testNS, *application, testRevision, newTestDynamicClient(application))
+	if err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	if destination != testDestination {
+		t.Errorf("expected %s, got: %s", testDestination, destination)
+	}
+}
+
+func TestCheckApplicationStatusRemoteDestination(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	application := newTestApplication(syncStatusSynced, healthStatusHealthy)
+	unstructured.SetNestedField(application.Object, "https://remote.example.com:6443", "spec", "destination", "server")
+	destination, err := checkApplicationStatus(testNS, *application, "", newTestDynamicClient(application))
+	if err != nil || destination != "" {
+		t.Fatalf("expected the remote destination to be skipped, got: %q %v", destination, err)
+	}
+	unstructured.RemoveNestedField(application.Object, "spec", "destination", "server")
+	unstructured.SetNestedField(application.Object, inClusterName, "spec", "destination", "name")
+	destination, err = checkApplicationStatus(testNS, *application, "", newTestDynamicClient(application))
+	if err != nil || destination != testDestination {
+		t.Fatalf("expected %s, got: %q %v", testDestination, destination, err)
+	}
+}
+
+func TestCheckApplicationStatusOutOfSync(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	application := newTestApplication("OutOfSync", healthStatusHealthy)
+	if _, err := checkApplicationStatus(testNS, *application, "", newTestDynamicClient(application)); !errors.Is(err, ErrApplicationNotSynced) {
+		t.Fatalf("expected ErrApplicationNotSynced, got: %v", err)
+	}
+}
+
+func TestCheckApplicationStatusRevision(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	application := newTestApplication(syncStatusSynced, healthStatusHealthy)
+	if _, err := checkApplicationStatus(testNS, *application, "deadbeef", newTestDynamicClient(application)); !errors.Is(err, ErrApplicationRevision) {
+		t.Fatalf("expected ErrApplicationRevision, got: %v", err)
+	}
+}
+
+func TestCheckApplicationStatusDegraded(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	application := newTestApplication(syncStatusSynced, "Progressing", newTestResource("StatefulSet", "db", healthDegraded))
+	if _, err := checkApplicationStatus(testNS, *application, "", newTestDynamicClient(application)); !errors.Is(err, ErrApplicationDegraded) {
+		t.Fatalf("expected ErrApplicationDegraded, got: %v", err)
+	}
+}
+
+func TestCheckApplicationStatusNotHealthy(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	application := newTestApplication(syncStatusSynced, "Missing")
+	if _, err := checkApplicationStatus(testNS, *application, "", newTestDynamicClient(application)); !errors.Is(err, ErrApplicationNotHealthy) {
+		t.Fatalf("expected ErrApplicationNotHealthy, got: %v", err)
+	}
+}
+
+func TestCheckApplications(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	dynamicClient := newTestDynamicClient(newTestApplication(syncStatusSynced, healthStatusHealthy))
+	interval := 1 * time.Second
+	timeout := 5 * time.Second
+	// the destination namespace holds no workloads, so the native checks are skipped
+	if err := CheckApplications([]string{testNS}, testRevision, true, dynamicClient, fake.NewSimpleClientset(), interval, timeout); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+}
+
+func TestCheckApplicationsFailed(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	dynamicClient := newTestDynamicClient(newTestApplication("OutOfSync", healthStatusHealthy))
+	interval := 1 * time.Second
+	timeout := 2 * time.Second
+	if err := CheckApplications([]string{testNS}, "", false, dynamicClient, fake.NewSimpleClientset(), interval, timeout); err == nil {
+		t.Fatalf("expected error, got: %v", err)
+	}
+}
+
+func TestCheckApplicationsNoNamespace(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	interval := 1 * time.Second
+	timeout := 5 * time.Second
+	if err := CheckApplications([]string{}, "", false, newTestDynamicClient(), fake.NewSimpleClientset(), interval, timeout); err != ErrNoNamespace {
+		t.Fatalf("expected ErrNoNamespace, got: %v", err)
+	}
+}
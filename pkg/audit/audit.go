@@ -0,0 +1,241 @@
+package audit
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/vprashar2929/integration-test/pkg/logger"
+	"github.com/vprashar2929/integration-test/pkg/workload"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Severity orders findings, findings at or above the configured fail severity fail the audit
+type Severity int
+
+const (
+	SeverityLow Severity = iota
+	SeverityMedium
+	SeverityHigh
+	// SeverityNone is above every finding, failing on it reports all findings as warnings. A rule set to it
+	// is silenced.
+	SeverityNone
+)
+
+var severityNames = map[Severity]string{
+	SeverityLow:    "low",
+	SeverityMedium: "medium",
+	SeverityHigh:   "high",
+	SeverityNone:   "none",
+}
+
+func (s Severity) String() string {
+	return severityNames[s]
+}
+
+// Set implements flag.Value so a severity can be passed on the command line
+func (s *Severity) Set(value string) error {
+	severity, err := ParseSeverity(value)
+	if err != nil {
+		return err
+	}
+	*s = severity
+	return nil
+}
+
+var (
+	ErrListingWorkload  = workload.ErrListingWorkload
+	ErrNoWorkload       = workload.ErrNoWorkload
+	ErrNoNamespace      = workload.ErrNoNamespace
+	ErrInvalidSeverity  = errors.New("invalid severity")
+	ErrAuditFailed      = errors.New("workload audit found failing issues")
+	ErrInvalidAuditRule = errors.New("invalid audit rule")
+)
+
+// ParseSeverity parses low, medium, high or none
+func ParseSeverity(value string) (Severity, error) {
+	for severity, name := range severityNames {
+		if strings.EqualFold(value, name) {
+			return severity, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %q, supported severities are low, medium, high, none", ErrInvalidSeverity, value)
+}
+
+// Rule names a single best-practice check along with its default severity
+type Rule struct {
+	Name     string
+	Severity Severity
+}
+
+var (
+	RuleLivenessProbe   = Rule{Name: "liveness-probe", Severity: SeverityMedium}
+	RuleReadinessProbe  = Rule{Name: "readiness-probe", Severity: SeverityMedium}
+	RuleRequests        = Rule{Name: "resource-requests", Severity: SeverityMedium}
+	RuleLimits          = Rule{Name: "resource-limits", Severity: SeverityLow}
+	RuleRunAsRoot       = Rule{Name: "run-as-root", Severity: SeverityHigh}
+	RulePrivileged      = Rule{Name: "privileged", Severity: SeverityHigh}
+	RuleHostPath        = Rule{Name: "host-path", Severity: SeverityHigh}
+	RuleSecurityContext = Rule{Name: "security-context", Severity: SeverityLow}
+)
+
+var rules = []*Rule{
+	&RuleLivenessProbe, &RuleReadinessProbe, &RuleRequests, &RuleLimits,
+	&RuleRunAsRoot, &RulePrivileged, &RuleHostPath, &RuleSecurityContext,
+}
+
+// SetRuleSeverity overrides the severity of a rule from <rule>=<severity>
+func SetRuleSeverity(value string) error {
+	name, level, found := strings.Cut(value, "=")
+	if !found {
+		return fmt.Errorf("%w: %q, expected <rule>=<severity>", ErrInvalidAuditRule, value)
+	}
+	severity, err := ParseSeverity(level)
+	if err != nil {
+		return err
+	}
+	for _, rule := range rules {
+		if rule.Name == name {
+			rule.Severity = severity
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %q", ErrInvalidAuditRule, name)
+}
+
+// Finding is a single issue found in the pod template of a workload
+type Finding struct {
+	Namespace string
+	Kind      string
+	Name      string
+	Container string
+	Rule      string
+	Severity  Severity
+	Message   string
+}
+
+func (f Finding) String() string {
+	object := f.Kind + " " + f.Name
+	if f.Container != "" {
+		object += " container " + f.Container
+	}
+	return fmt.Sprintf("[%s] %s in namespace %s: %s (%s)", f.Severity, object, f.Namespace, f.Message, f.Rule)
+}
+
+// runsAsRoot resolves the effective user of a container, the container security context overrides the pod one.
+// explicit reports whether uid 0 is set rather than left to the image default.
+func runsAsRoot(pod *corev1.PodSecurityContext, container *corev1.SecurityContext) (root bool, explicit bool) {
+	var runAsUser *int64
+	var runAsNonRoot *bool
+	if pod != nil {
+		runAsUser, runAsNonRoot = pod.RunAsUser, pod.RunAsNonRoot
+	}
+	if container != nil {
+		if container.RunAsUser != nil {
+			runAsUser = container.RunAsUser
+		}
+		if container.RunAsNonRoot != nil {
+			runAsNonRoot = container.RunAsNonRoot
+		}
+	}
+	if runAsUser != nil {
+		return *runAsUser == 0, *runAsUser == 0
+	}
+	return runAsNonRoot == nil || !*runAsNonRoot, false
+}
+
+func auditContainer(spec corev1.PodSpec, container corev1.Container, init bool) []Finding {
+	var findings []Finding
+	add := func(rule Rule, message string) {
+		findings = append(findings, Finding{Container: container.Name, Rule: rule.Name, Severity: rule.Severity, Message: message})
+	}
+	// init containers run to completion, probes do not apply to them
+	if !init {
+		if container.LivenessProbe == nil {
+			add(RuleLivenessProbe, "no liveness probe")
+		}
+		if container.ReadinessProbe == nil {
+			add(RuleReadinessProbe, "no readiness probe")
+		}
+	}
+	for _, resource := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		if _, ok := container.Resources.Requests[resource]; !ok {
+			add(RuleRequests, "no "+string(resource)+" request")
+		}
+		if _, ok := container.Resources.Limits[resource]; !ok {
+			add(RuleLimits, "no "+string(resource)+" limit")
+		}
+	}
+	if root, explicit := runsAsRoot(spec.SecurityContext, container.SecurityContext); root {
+		if explicit {
+			add(RuleRunAsRoot, "runs as uid 0")
+		} else {
+			add(RuleRunAsRoot, "may run as root, runAsNonRoot is not set")
+		}
+	}
+	if sc := container.SecurityContext; sc != nil && sc.Privileged != nil && *sc.Privileged {
+		add(RulePrivileged, "runs privileged")
+	}
+	if spec.SecurityContext == nil && container.SecurityContext == nil {
+		add(RuleSecurityContext, "no securityContext")
+	}
+	return findings
+}
+
+// auditWorkload returns the findings for the pod template of a workload
+func auditWorkload(namespace string, w workload.Workload) []Finding {
+	var findings []Finding
+	for _, volume := range w.Spec.Volumes {
+		if volume.HostPath != nil {
+			findings = append(findings, Finding{Rule: RuleHostPath.Name, Severity: RuleHostPath.Severity, Message: "mounts hostPath " + volume.HostPath.Path})
+		}
+	}
+	for _, container := range w.Spec.InitContainers {
+		findings = append(findings, auditContainer(w.Spec, container, true)...)
+	}
+	for _, container := range w.Spec.Containers {
+		findings = append(findings, auditContainer(w.Spec, container, false)...)
+	}
+	for i := range findings {
+		findings[i].Namespace, findings[i].Kind, findings[i].Name = namespace, w.Kind, w.Name
+	}
+	return findings
+}
+
+// CheckAudit audits the pod templates of every workload. Findings below failSeverity are logged as warnings,
+// the others as errors and fail the audit.
+func CheckAudit(namespaces []string, failSeverity Severity, clientset kubernetes.Interface) error {
+	logger.AppLog.LogInfo("Begin workload audit")
+
+	workloadsByNamespace, err := workload.ListByNamespace(namespaces, clientset)
+	if err != nil {
+		if errors.Is(err, ErrNoWorkload) {
+			logger.AppLog.LogWarning("No workloads found. Skipping audit.")
+			return nil
+		}
+		return err
+	}
+	failed := 0
+	for _, namespace := range namespaces {
+		for _, w := range workloadsByNamespace[namespace] {
+			for _, finding := range auditWorkload(namespace, w) {
+				if finding.Severity == SeverityNone {
+					continue
+				}
+				if finding.Severity >= failSeverity {
+					logger.AppLog.LogError("%s\n", finding)
+					failed++
+					continue
+				}
+				logger.AppLog.LogWarning("%s\n", finding)
+			}
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%w: %d findings at or above %s severity", ErrAuditFailed, failed, failSeverity)
+	}
+
+	logger.AppLog.LogInfo("End workload audit")
+	return nil
+}
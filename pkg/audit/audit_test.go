@@ -0,0 +1,134 @@
+package audit
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/vprashar2929/integration-test/pkg/logger"
+	"github.com/vprashar2929/integration-test/pkg/workload"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var (
+	testNS         = "test-namespace"
+	testDep        = "test-deployment"
+	testDeployment = appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: testDep, Namespace: testNS}}
+)
+
+func newTestPodSpec() corev1.PodSpec {
+	nonRoot := true
+	quantity := resource.MustParse("100m")
+	resources := corev1.ResourceList{corev1.ResourceCPU: quantity, corev1.ResourceMemory: resource.MustParse("64Mi")}
+	probe := &corev1.Probe{ProbeHandler: corev1.ProbeHandler{TCPSocket: &corev1.TCPSocketAction{}}}
+	return corev1.PodSpec{
+		SecurityContext: &corev1.PodSecurityContext{RunAsNonRoot: &nonRoot},
+		Containers: []corev1.Container{
+			{
+				Name:           "app",
+				LivenessProbe:  probe,
+				ReadinessProbe: probe,
+				Resources:      corev1.ResourceRequirements{Requests: resources, Limits: resources},
+			},
+		},
+	}
+}
+
+func hasRule(findings []Finding, rule string) bool {
+	for _, finding := range findings {
+		if finding.Rule == rule {
+			return true
+		}
+	}
+	return false
+}
+
+func TestParseSeverity(t *testing.T) {
+	if severity, err := ParseSeverity("Medium"); err != nil || severity != SeverityMedium {
+		t.Fatalf("expected SeverityMedium, got: %v %v", severity, err)
+	}
+	if _, err := ParseSeverity("critical"); !errors.Is(err, ErrInvalidSeverity) {
+		t.Fatalf("expected ErrInvalidSeverity, got: %v", err)
+	}
+}
+
+func TestAuditWorkloadClean(t *testing.T) {
+	if findings := auditWorkload(testNS, workload.Workload{Kind: workload.KindDeployment, Name: testDep, Spec: newTestPodSpec()}); len(findings) != 0 {
+		t.Fatalf("expected no findings, got: %v", findings)
+	}
+}
+
+func TestAuditWorkload(t *testing.T) {
+	privileged := true
+	var root int64
+	spec := corev1.PodSpec{
+		Containers: []corev1.Container{{Name: "app", SecurityContext: &corev1.SecurityContext{Privileged: &privileged, RunAsUser: &root}}},
+		Volumes:    []corev1.Volume{{Name: "host", VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/var/run"}}}},
+	}
+	findings := auditWorkload(testNS, workload.Workload{Kind: workload.KindDeployment, Name: testDep, Spec: spec})
+	for _, rule := range []string{"liveness-probe", "readiness-probe", "resource-requests", "resource-limits", "run-as-root", "privileged", "host-path"} {
+		if !hasRule(findings, rule) {
+			t.Errorf("expected %s finding, got: %v", rule, findings)
+		}
+	}
+	if hasRule(findings, "security-context") {
+		t.Errorf("expected no security-context finding, got: %v", findings)
+	}
+	spec = corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}}
+	if findings = auditWorkload(testNS, workload.Workload{Kind: workload.KindDeployment, Name: testDep, Spec: spec}); !hasRule(findings, "security-context") {
+		t.Errorf("expected security-context finding, got: %v", findings)
+	}
+}
+
+func TestSetRuleSeverity(t *testing.T) {
+	defer func() { RulePrivileged.Severity = SeverityHigh }()
+	if err := SetRuleSeverity("privileged=low"); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	if RulePrivileged.Severity != SeverityLow {
+		t.Fatalf("expected SeverityLow, got: %v", RulePrivileged.Severity)
+	}
+	if err := SetRuleSeverity("unknown=low"); !errors.Is(err, ErrInvalidAuditRule) {
+		t.Fatalf("expected ErrInvalidAuditRule, got: %v", err)
+	}
+}
+
+func TestCheckAuditSilencedRule(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	defer func(severity Severity) { RuleLivenessProbe.Severity = severity }(RuleLivenessProbe.Severity)
+	if err := SetRuleSeverity("liveness-probe=none"); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	spec := newTestPodSpec()
+	spec.Containers[0].LivenessProbe = nil
+	deployment := testDeployment
+	deployment.Spec.Template.Spec = spec
+	if err := CheckAudit([]string{testNS}, SeverityLow, fake.NewSimpleClientset(&deployment)); err != nil {
+		t.Fatalf("expected the silenced rule to pass, got: %v", err)
+	}
+}
+
+func TestCheckAudit(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	spec := newTestPodSpec()
+	spec.Containers[0].LivenessProbe = nil
+	deployment := testDeployment
+	deployment.Spec.Template.Spec = spec
+	clientset := fake.NewSimpleClientset(&deployment)
+	if err := CheckAudit([]string{testNS}, SeverityHigh, clientset); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	if err := CheckAudit([]string{testNS}, SeverityMedium, clientset); !errors.Is(err, ErrAuditFailed) {
+		t.Fatalf("expected ErrAuditFailed, got: %v", err)
+	}
+}
+
+func TestCheckAuditNoNamespace(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	if err := CheckAudit([]string{}, SeverityHigh, fake.NewSimpleClientset()); err != ErrNoNamespace {
+		t.Fatalf("expected ErrNoNamespace, got: %v", err)
+	}
+}
@@ -3,12 +3,13 @@ package client
 import (
 	"github.com/vprashar2929/integration-test/pkg/logger"
 
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 )
 
-func GetClient(kubeconfig string) *kubernetes.Clientset {
+func GetConfig(kubeconfig string) *rest.Config {
 	var (
 		config *rest.Config
 		err    error
@@ -26,10 +27,23 @@ func GetClient(kubeconfig string) *kubernetes.Clientset {
 			logger.AppLog.LogFatal("Error building kubeconfig from file %s: %v\n", kubeconfig, err)
 		}
 	}
+	return config
+}
+
+func GetClient(kubeconfig string) *kubernetes.Clientset {
 	// Create Kubernetes clientset
-	clientset, err := kubernetes.NewForConfig(config)
+	clientset, err := kubernetes.NewForConfig(GetConfig(kubeconfig))
 	if err != nil {
 		logger.AppLog.LogFatal("Error creating Kubernetes clientset: %v\n", err)
 	}
 	return clientset
 }
+
+func GetDynamicClient(kubeconfig string) dynamic.Interface {
+	// Create dynamic client for custom resources
+	dynamicClient, err := dynamic.NewForConfig(GetConfig(kubeconfig))
+	if err != nil {
+		logger.AppLog.LogFatal("Error creating Kubernetes dynamic client: %v\n", err)
+	}
+	return dynamicClient
+}
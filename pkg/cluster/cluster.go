@@ -0,0 +1,203 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/vprashar2929/integration-test/pkg/deployment"
+	"github.com/vprashar2929/integration-test/pkg/logger"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	SystemNamespace = "kube-system"
+	// CoreDNS keeps the kube-dns label for compatibility with the addon it replaced
+	coreDNSSelector = "k8s-app=kube-dns"
+)
+
+// apiServerHealthPaths are probed in order, liveness first so a dead server is reported as such
+var apiServerHealthPaths = []string{"/livez", "/readyz"}
+
+// pressureConditions must all be False on a healthy node
+var pressureConditions = []corev1.NodeConditionType{
+	corev1.NodeMemoryPressure,
+	corev1.NodeDiskPressure,
+	corev1.NodePIDPressure,
+	corev1.NodeNetworkUnavailable,
+}
+
+var (
+	ErrListingNodes        = errors.New("error listing nodes")
+	ErrNoNode              = errors.New("no nodes found in cluster")
+	ErrNodeNotReady        = errors.New("node not ready")
+	ErrNodePressure        = errors.New("node reports pressure condition")
+	ErrNodesNotHealthy     = errors.New("nodes not healthy")
+	ErrAPIServerNotHealthy = errors.New("api server health check failed")
+	ErrListingSystem       = errors.New("error listing kube-system resources")
+	ErrSystemPodNotHealthy = errors.New("kube-system pod not healthy")
+	ErrInvalidInterval     = errors.New("interval or timeout is invalid")
+)
+
+func checkAPIServerHealth(restClient rest.Interface) error {
+	for _, path := range apiServerHealthPaths {
+		body, err := restClient.Get().AbsPath(path).DoRaw(context.TODO())
+		if err != nil {
+			return fmt.Errorf("%w: %s: %v %s", ErrAPIServerNotHealthy, path, err, strings.TrimSpace(string(body)))
+		}
+		logger.AppLog.LogInfo("api server %s is ok\n", path)
+	}
+	return nil
+}
+
+func getNodeCondition(node *corev1.Node, conditionType corev1.NodeConditionType) *corev1.NodeCondition {
+	for i := range node.Status.Conditions {
+		if node.Status.Conditions[i].Type == conditionType {
+			return &node.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+func checkNodeStatus(node *corev1.Node) error {
+	ready := getNodeCondition(node, corev1.NodeReady)
+	if ready == nil || ready.Status != corev1.ConditionTrue {
+		return fmt.Errorf("%w: %s", ErrNodeNotReady, node.Name)
+	}
+	for _, conditionType := range pressureConditions {
+		if condition := getNodeCondition(node, conditionType); condition != nil && condition.Status == corev1.ConditionTrue {
+			return fmt.Errorf("%w: %s has %s: %s", ErrNodePressure, node.Name, conditionType, condition.Message)
+		}
+	}
+	return nil
+}
+
+func checkNodes(clientset kubernetes.Interface) error {
+	nodes, err := clientset.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		logger.AppLog.LogError("error listing nodes: %v\n", err)
+		return ErrListingNodes
+	}
+	if len(nodes.Items) == 0 {
+		return ErrNoNode
+	}
+	// report every unhealthy node at once, cordoned nodes are routine maintenance and only logged
+	var notHealthy []string
+	for i := range nodes.Items {
+		if nodes.Items[i].Spec.Unschedulable {
+			logger.AppLog.LogWarning("node %s is cordoned\n", nodes.Items[i].Name)
+		}
+		if err := checkNodeStatus(&nodes.Items[i]); err != nil {
+			notHealthy = append(notHealthy, err.Error())
+		}
+	}
+	if len(notHealthy) > 0 {
+		return fmt.Errorf("%w: %s", ErrNodesNotHealthy, strings.Join(notHealthy, "; "))
+	}
+	logger.AppLog.LogInfo("all %d nodes are ready\n", len(nodes.Items))
+	return nil
+}
+
+func isPodHealthy(pod *corev1.Pod) bool {
+	if pod.Status.Phase != corev1.PodRunning {
+		return false
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+func checkSystemPods(clientset kubernetes.Interface) error {
+	pods, err := clientset.CoreV1().Pods(SystemNamespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		logger.AppLog.LogError("error listing pods in namespace %s: %v\n", SystemNamespace, err)
+		return ErrListingSystem
+	}
+	// terminal pods never recover, completed jobs are fine and failed or evicted pods are left for cleanup while
+	// their controllers run replacements that are checked instead
+	var notHealthy []string
+	checked := 0
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		switch pod.Status.Phase {
+		case corev1.PodSucceeded:
+			continue
+		case corev1.PodFailed:
+			logger.AppLog.LogWarning("skipping failed pod %s in namespace %s, reason: %s\n", pod.Name, SystemNamespace, pod.Status.Reason)
+			continue
+		}
+		checked++
+		if !isPodHealthy(pod) {
+			notHealthy = append(notHealthy, fmt.Sprintf("%s is %s", pod.Name, pod.Status.Phase))
+		}
+	}
+	if len(notHealthy) > 0 {
+		return fmt.Errorf("%w: %s", ErrSystemPodNotHealthy, strings.Join(notHealthy, ", "))
+	}
+	logger.AppLog.LogInfo("all %d pods are healthy in namespace %s\n", checked, SystemNamespace)
+	return nil
+}
+
+// getCoreDNSDeployments returns the names of the cluster DNS deployments, none on distributions that run DNS differently
+func getCoreDNSDeployments(clientset kubernetes.Interface) ([]string, error) {
+	deployments, err := clientset.AppsV1().Deployments(SystemNamespace).List(context.TODO(), metav1.ListOptions{LabelSelector: coreDNSSelector})
+	if err != nil {
+		logger.AppLog.LogError("error listing deployments in namespace %s: %v\n", SystemNamespace, err)
+		return nil, ErrListingSystem
+	}
+	var names []string
+	for _, deployment := range deployments.Items {
+		names = append(names, deployment.Name)
+	}
+	return names, nil
+}
+
+func CheckCluster(clientset kubernetes.Interface, restClient rest.Interface, interval, timeout time.Duration) error {
+	var err error
+	logger.AppLog.LogInfo("Begin Cluster validation")
+
+	if interval <= 0 || timeout <= 0 {
+		return ErrInvalidInterval
+	}
+	checks := []struct {
+		name  string
+		check func() error
+	}{
+		{"api server", func() error { return checkAPIServerHealth(restClient) }},
+		{"nodes", func() error { return checkNodes(clientset) }},
+		{"kube-system pods", func() error { return checkSystemPods(clientset) }},
+	}
+	for _, c := range checks {
+		deadline := time.Now().Add(timeout)
+		for time.Now().Before(deadline) {
+			if err = c.check(); err == nil {
+				break
+			}
+			time.Sleep(interval)
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timeout checking %s, error: %v", c.name, err)
+		}
+	}
+
+	coreDNS, err := getCoreDNSDeployments(clientset)
+	if err != nil {
+		return err
+	}
+	if len(coreDNS) == 0 {
+		logger.AppLog.LogWarning("No CoreDNS deployment found. Skipping validations.")
+	} else if err = deployment.CheckNamedDeployments(SystemNamespace, coreDNS, clientset, interval, timeout); err != nil {
+		return fmt.Errorf("coredns not healthy, error: %w", err)
+	}
+
+	logger.AppLog.LogInfo("End Cluster validation")
+	return nil
+}
@@ -0,0 +1,176 @@
+package cluster
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vprashar2929/integration-test/pkg/logger"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+)
+
+var testLabels = map[string]string{"k8s-app": "kube-dns"}
+
+func newTestNode(name string, ready corev1.ConditionStatus, conditions ...corev1.NodeCondition) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: corev1.NodeStatus{
+			Conditions: append([]corev1.NodeCondition{{Type: corev1.NodeReady, Status: ready}}, conditions...),
+		},
+	}
+}
+
+func newTestPod(name string, phase corev1.PodPhase, ready corev1.ConditionStatus) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: SystemNamespace, Labels: testLabels},
+		Status: corev1.PodStatus{
+			Phase:      phase,
+			Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: ready}},
+		},
+	}
+}
+
+func newTestCoreDNS() *appsv1.Deployment {
+	replicas := int32(1)
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "coredns",
+			Namespace:   SystemNamespace,
+			Labels:      testLabels,
+			Generation:  1,
+			Annotations: map[string]string{"deployment.kubernetes.io/revision": "1"},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: testLabels},
+		},
+		Status: appsv1.DeploymentStatus{
+			ObservedGeneration: 1,
+			Replicas:           1,
+			UpdatedReplicas:    1,
+			ReadyReplicas:      1,
+			AvailableReplicas:  1,
+		},
+	}
+}
+
+// newTestRESTClient returns a client for a local api server that answers the health endpoints with the given status
+func newTestRESTClient(t *testing.T, readyz int) rest.Interface {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/livez", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(readyz)
+		w.Write([]byte("[-]etcd failed: reason withheld"))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	clientset, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return clientset.Discovery().RESTClient()
+}
+
+func TestCheckAPIServerHealth(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	if err := checkAPIServerHealth(newTestRESTClient(t, http.StatusOK)); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	if err := checkAPIServerHealth(newTestRESTClient(t, http.StatusInternalServerError)); !errors.Is(err, ErrAPIServerNotHealthy) {
+		t.Fatalf("expected ErrAPIServerNotHealthy, got: %v", err)
+	}
+}
+
+func TestCheckNodeStatus(t *testing.T) {
+	if err := checkNodeStatus(newTestNode("ready", corev1.ConditionTrue)); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	if err := checkNodeStatus(newTestNode("notready", corev1.ConditionUnknown)); !errors.Is(err, ErrNodeNotReady) {
+		t.Fatalf("expected ErrNodeNotReady, got: %v", err)
+	}
+	pressure := newTestNode("pressure", corev1.ConditionTrue, corev1.NodeCondition{Type: corev1.NodeDiskPressure, Status: corev1.ConditionTrue})
+	if err := checkNodeStatus(pressure); !errors.Is(err, ErrNodePressure) {
+		t.Fatalf("expected ErrNodePressure, got: %v", err)
+	}
+}
+
+func TestCheckNodes(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	cordoned := newTestNode("cordoned", corev1.ConditionTrue)
+	cordoned.Spec.Unschedulable = true
+	if err := checkNodes(fake.NewSimpleClientset(cordoned)); err != nil {
+		t.Fatalf("expected a cordoned node to pass, got: %v", err)
+	}
+	pressure := newTestNode("pressure", corev1.ConditionTrue, corev1.NodeCondition{Type: corev1.NodeDiskPressure, Status: corev1.ConditionTrue})
+	err := checkNodes(fake.NewSimpleClientset(newTestNode("notready", corev1.ConditionFalse), pressure, cordoned))
+	if !errors.Is(err, ErrNodesNotHealthy) {
+		t.Fatalf("expected ErrNodesNotHealthy, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), "notready") || !strings.Contains(err.Error(), "pressure") {
+		t.Fatalf("expected both nodes in error, got: %v", err)
+	}
+}
+
+func TestCheckNodesNoNode(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	if err := checkNodes(fake.NewSimpleClientset()); err != ErrNoNode {
+		t.Fatalf("expected ErrNoNode, got: %v", err)
+	}
+}
+
+func TestCheckSystemPods(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	clientset := fake.NewSimpleClientset(
+		newTestPod("coredns-abcde", corev1.PodRunning, corev1.ConditionTrue),
+		newTestPod("install-job", corev1.PodSucceeded, corev1.ConditionFalse),
+		newTestPod("coredns-evicted", corev1.PodFailed, corev1.ConditionFalse),
+	)
+	if err := checkSystemPods(clientset); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	clientset = fake.NewSimpleClientset(newTestPod("kube-proxy-abcde", corev1.PodRunning, corev1.ConditionFalse))
+	if err := checkSystemPods(clientset); !errors.Is(err, ErrSystemPodNotHealthy) {
+		t.Fatalf("expected ErrSystemPodNotHealthy, got: %v", err)
+	}
+}
+
+func TestCheckCluster(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	clientset := fake.NewSimpleClientset(
+		newTestNode("node-1", corev1.ConditionTrue),
+		newTestPod("coredns-abcde", corev1.PodRunning, corev1.ConditionTrue),
+		newTestCoreDNS(),
+	)
+	interval := 1 * time.Second
+	timeout := 5 * time.Second
+	if err := CheckCluster(clientset, newTestRESTClient(t, http.StatusOK), interval, timeout); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+}
+
+func TestCheckClusterNodeNotReady(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	clientset := fake.NewSimpleClientset(newTestNode("node-1", corev1.ConditionFalse))
+	interval := 1 * time.Second
+	timeout := 2 * time.Second
+	if err := CheckCluster(clientset, newTestRESTClient(t, http.StatusOK), interval, timeout); err == nil {
+		t.Fatalf("expected error, got: %v", err)
+	}
+}
+
+func TestCheckClusterInvalidInterval(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	if err := CheckCluster(fake.NewSimpleClientset(), newTestRESTClient(t, http.StatusOK), 0, 0); err != ErrInvalidInterval {
+		t.Fatalf("expected ErrInvalidInterval, got: %v", err)
+	}
+}
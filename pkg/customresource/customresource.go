@@ -0,0 +1,215 @@
+package customresource
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/vprashar2929/integration-test/pkg/logger"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+var (
+	ErrListingCustomResource    = errors.New("error listing custom resources in namespace")
+	ErrNoCustomResource         = errors.New("no custom resources found in namespace")
+	ErrNoNamespace              = errors.New("no namespace provided")
+	ErrCustomResourceNotHealthy = errors.New("custom resource not in expected state")
+	ErrInvalidInterval          = errors.New("interval or timeout is invalid")
+	ErrInvalidCheck             = errors.New("invalid custom resource check")
+)
+
+// Check describes what a healthy custom resource looks like. Either a status condition type/status pair
+// or a JSONPath expression and the value it must evaluate to.
+type Check struct {
+	GVR             schema.GroupVersionResource
+	ConditionType   string
+	ConditionStatus string
+	JSONPath        string
+	Expected        string
+}
+
+func (c Check) String() string {
+	expectation := c.ConditionType + "=" + c.ConditionStatus
+	if c.JSONPath != "" {
+		expectation = c.JSONPath + "=" + c.Expected
+	}
+	return c.GVR.String() + " " + expectation
+}
+
+// ParseCheck parses <group>/<version>/<resource>[:<condition>[=<status>]] or
+// <group>/<version>/<resource>:{<jsonpath>}=<value>. The group is left out for core resources and
+// the condition defaults to Ready=True.
+func ParseCheck(spec string) (Check, error) {
+	check := Check{ConditionType: "Ready", ConditionStatus: "True"}
+	resourcePart, expectation, hasExpectation := strings.Cut(spec, ":")
+	parts := strings.Split(resourcePart, "/")
+	if len(parts) < 2 || parts[len(parts)-1] == "" || parts[len(parts)-2] == "" {
+		return check, fmt.Errorf("%w: %q, expected <group>/<version>/<resource>", ErrInvalidCheck, spec)
+	}
+	check.GVR = schema.GroupVersionResource{
+		Group:    strings.Join(parts[:len(parts)-2], "/"),
+		Version:  parts[len(parts)-2],
+		Resource: parts[len(parts)-1],
+	}
+	if !hasExpectation {
+		return check, nil
+	}
+	if strings.HasPrefix(expectation, "{") {
+		end := strings.LastIndex(expectation, "}=")
+		if end < 0 {
+			return check, fmt.Errorf("%w: %q, expected {<jsonpath>}=<value>", ErrInvalidCheck, spec)
+		}
+		check.JSONPath = expectation[:end+1]
+		check.Expected = expectation[end+2:]
+		if err := jsonpath.New(check.GVR.Resource).Parse(check.JSONPath); err != nil {
+			return check, fmt.Errorf("%w: %q: %v", ErrInvalidCheck, spec, err)
+		}
+		return check, nil
+	}
+	conditionType, status, hasStatus := strings.Cut(expectation, "=")
+	if conditionType == "" {
+		return check, fmt.Errorf("%w: %q, empty condition type", ErrInvalidCheck, spec)
+	}
+	check.ConditionType = conditionType
+	if hasStatus {
+		check.ConditionStatus = status
+	}
+	return check, nil
+}
+
+func getCustomResource(namespace string, check Check, dynamicClient dynamic.Interface) (*unstructured.UnstructuredList, error) {
+	resources, err := dynamicClient.Resource(check.GVR).Namespace(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		logger.AppLog.LogError("error listing %s in namespace %s: %v\n", check.GVR.String(), namespace, err)
+		return nil, ErrListingCustomResource
+	}
+	if len(resources.Items) == 0 {
+		return nil, ErrNoCustomResource
+	}
+	return resources, nil
+}
+
+func storeCustomResourcesByNamespace(namespaces []string, check Check, dynamicClient dynamic.Interface) (map[string][]unstructured.Unstructured, error) {
+	if len(namespaces) == 0 {
+		return nil, ErrNoNamespace
+	}
+	resourcesByNamespace := make(map[string][]unstructured.Unstructured)
+	for _, namespace := range namespaces {
+		if namespace == "" {
+			logger.AppLog.LogError("Invalid namespace provided.")
+			continue
+		}
+		resourceList, err := getCustomResource(namespace, check, dynamicClient)
+		if err != nil {
+			if errors.Is(err, ErrNoCustomResource) {
+				continue
+			}
+			return nil, err
+		}
+		resourcesByNamespace[namespace] = resourceList.Items
+	}
+	if len(resourcesByNamespace) == 0 {
+		return nil, ErrNoCustomResource
+	}
+	return resourcesByNamespace, nil
+}
+
+func evaluateJSONPath(object map[string]interface{}, expression string) (string, error) {
+	parser := jsonpath.New("check").AllowMissingKeys(true)
+	if err := parser.Parse(expression); err != nil {
+		return "", err
+	}
+	var out bytes.Buffer
+	if err := parser.Execute(&out, object); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+func matchesCheck(resource *unstructured.Unstructured, check Check) (string, bool, error) {
+	if check.JSONPath != "" {
+		value, err := evaluateJSONPath(resource.Object, check.JSONPath)
+		if err != nil {
+			return "", false, err
+		}
+		return check.JSONPath + "=" + value, value == check.Expected, nil
+	}
+	conditions, _, _ := unstructured.NestedSlice(resource.Object, "status", "conditions")
+	for _, condition := range conditions {
+		conditionMap, ok := condition.(map[string]interface{})
+		if !ok || conditionMap["type"] != check.ConditionType {
+			continue
+		}
+		status, _ := conditionMap["status"].(string)
+		message, _ := conditionMap["message"].(string)
+		return check.ConditionType + "=" + status + " " + message, status == check.ConditionStatus, nil
+	}
+	return "condition " + check.ConditionType + " not reported", false, nil
+}
+
+func checkCustomResourceStatus(namespace string, resource unstructured.Unstructured, check Check, dynamicClient dynamic.Interface) error {
+	updatedResource, err := dynamicClient.Resource(check.GVR).Namespace(namespace).Get(context.TODO(), resource.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	observed, ok, err := matchesCheck(updatedResource, check)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrCustomResourceNotHealthy, observed)
+	}
+	logger.AppLog.LogInfo("%s %s is healthy in namespace %s\n", check.GVR.Resource, resource.GetName(), namespace)
+	return nil
+}
+
+func validateCustomResourcesByNamespace(namespaces []string, resourcesByNamespace map[string][]unstructured.Unstructured, check Check, dynamicClient dynamic.Interface, interval, timeout time.Duration) error {
+	var err error
+	if interval <= 0 || timeout <= 0 {
+		return ErrInvalidInterval
+	}
+	for _, namespace := range namespaces {
+		for _, resource := range resourcesByNamespace[namespace] {
+			deadline := time.Now().Add(timeout)
+			for time.Now().Before(deadline) {
+				if err = checkCustomResourceStatus(namespace, resource, check, dynamicClient); err == nil {
+					break
+				}
+				time.Sleep(interval)
+			}
+			if time.Now().After(deadline) {
+				return fmt.Errorf("timeout checking %s status for %s in namespace %s, error: %v", check.GVR.Resource, resource.GetName(), namespace, err)
+			}
+		}
+	}
+	return nil
+}
+
+func CheckCustomResources(namespaces []string, checks []Check, dynamicClient dynamic.Interface, interval, timeout time.Duration) error {
+	logger.AppLog.LogInfo("Begin Custom Resource validation")
+
+	for _, check := range checks {
+		logger.AppLog.LogInfo("Checking %s\n", check)
+		resourcesByNamespace, err := storeCustomResourcesByNamespace(namespaces, check, dynamicClient)
+		if err != nil {
+			if errors.Is(err, ErrNoCustomResource) {
+				logger.AppLog.LogWarning("No %s found. Skipping validations.", check.GVR.Resource)
+				continue
+			}
+			return err
+		}
+		if err = validateCustomResourcesByNamespace(namespaces, resourcesByNamespace, check, dynamicClient, interval, timeout); err != nil {
+			return err
+		}
+	}
+
+	logger.AppLog.LogInfo("End Custom Resource validation")
+	return nil
+}
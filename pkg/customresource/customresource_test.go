@@ -0,0 +1,134 @@
+package customresource
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vprashar2929/integration-test/pkg/logger"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+var (
+	testNS  = "test-namespace"
+	testGVR = schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "widgets"}
+)
+
+func newTestWidget(name, ready, phase string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "example.com/v1",
+			"kind":       "Widget",
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": testNS,
+			},
+			"status": map[string]interface{}{
+				"phase": phase,
+				"conditions": []interface{}{
+					map[string]interface{}{"type": "Ready", "status": ready, "message": "reconciled"},
+				},
+			},
+		},
+	}
+}
+
+func newTestClient(objects ...runtime.Object) *dynamicfake.FakeDynamicClient {
+	return dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{testGVR: "WidgetList"}, objects...)
+}
+
+func TestParseCheck(t *testing.T) {
+	check, err := ParseCheck("example.com/v1/widgets")
+	if err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	if check.GVR != testGVR || check.ConditionType != "Ready" || check.ConditionStatus != "True" {
+		t.Errorf("expected widgets Ready=True, got: %+v", check)
+	}
+
+	check, err = ParseCheck("example.com/v1/widgets:Synced=False")
+	if err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	if check.ConditionType != "Synced" || check.ConditionStatus != "False" {
+		t.Errorf("expected Synced=False, got: %+v", check)
+	}
+
+	check, err = ParseCheck("v1/configmaps:{.data.state}=ok")
+	if err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	if check.GVR.Group != "" || check.JSONPath != "{.data.state}" || check.Expected != "ok" {
+		t.Errorf("expected core configmaps with jsonpath, got: %+v", check)
+	}
+}
+
+func TestParseCheckInvalid(t *testing.T) {
+	for _, spec := range []string{"widgets", "example.com/v1/widgets:{.status.phase", "example.com/v1/widgets:"} {
+		if _, err := ParseCheck(spec); !errors.Is(err, ErrInvalidCheck) {
+			t.Errorf("expected ErrInvalidCheck for %q, got: %v", spec, err)
+		}
+	}
+}
+
+func TestCheckCustomResourceStatusCondition(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	check, _ := ParseCheck("example.com/v1/widgets:Ready")
+	widget := newTestWidget("healthy", "True", "Running")
+	if err := checkCustomResourceStatus(testNS, *widget, check, newTestClient(widget)); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	widget = newTestWidget("unhealthy", "False", "Running")
+	if err := checkCustomResourceStatus(testNS, *widget, check, newTestClient(widget)); !errors.Is(err, ErrCustomResourceNotHealthy) {
+		t.Fatalf("expected ErrCustomResourceNotHealthy, got: %v", err)
+	}
+}
+
+func TestCheckCustomResourceStatusJSONPath(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	check, _ := ParseCheck("example.com/v1/widgets:{.status.phase}=Running")
+	widget := newTestWidget("healthy", "False", "Running")
+	if err := checkCustomResourceStatus(testNS, *widget, check, newTestClient(widget)); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	widget = newTestWidget("pending", "True", "Pending")
+	if err := checkCustomResourceStatus(testNS, *widget, check, newTestClient(widget)); !errors.Is(err, ErrCustomResourceNotHealthy) {
+		t.Fatalf("expected ErrCustomResourceNotHealthy, got: %v", err)
+	}
+}
+
+func TestCheckCustomResources(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	check, _ := ParseCheck("example.com/v1/widgets")
+	dynamicClient := newTestClient(newTestWidget("one", "True", "Running"), newTestWidget("two", "True", "Running"))
+	interval := 1 * time.Second
+	timeout := 5 * time.Second
+	if err := CheckCustomResources([]string{testNS}, []Check{check}, dynamicClient, interval, timeout); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+}
+
+func TestCheckCustomResourcesFailed(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	check, _ := ParseCheck("example.com/v1/widgets")
+	dynamicClient := newTestClient(newTestWidget("one", "False", "Running"))
+	interval := 1 * time.Second
+	timeout := 2 * time.Second
+	if err := CheckCustomResources([]string{testNS}, []Check{check}, dynamicClient, interval, timeout); err == nil {
+		t.Fatalf("expected error, got: %v", err)
+	}
+}
+
+func TestCheckCustomResourcesNoNamespace(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	check, _ := ParseCheck("example.com/v1/widgets")
+	interval := 1 * time.Second
+	timeout := 5 * time.Second
+	if err := CheckCustomResources([]string{}, []Check{check}, newTestClient(), interval, timeout); err != ErrNoNamespace {
+		t.Fatalf("expected ErrNoNamespace, got: %v", err)
+	}
+}
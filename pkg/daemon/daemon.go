@@ -0,0 +1,115 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/vprashar2929/integration-test/pkg/logger"
+	"github.com/vprashar2929/integration-test/pkg/metrics"
+)
+
+const shutdownTimeout = 10 * time.Second
+
+var ErrInvalidSchedule = errors.New("schedule is invalid")
+
+// Daemon re-runs the checks on a schedule and serves their metrics along with its own health
+type Daemon struct {
+	addr     string
+	schedule time.Duration
+	run      func() error
+	ready    atomic.Bool
+}
+
+// New returns a daemon listening on addr that calls run every schedule
+func New(addr string, schedule time.Duration, run func() error) *Daemon {
+	return &Daemon{addr: addr, schedule: schedule, run: run}
+}
+
+// Handler serves /metrics, /healthz and /readyz. The daemon is ready once the first run finished, whatever
+// its result, since failing checks are reported through the metrics.
+func (d *Daemon) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		if !d.ready.Load() {
+			http.Error(w, "first run not finished", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	})
+	return mux
+}
+
+func (d *Daemon) runOnce() {
+	metrics.BeginRun()
+	start := time.Now()
+	err := d.run()
+	metrics.EndRun()
+	metrics.ObserveRun(time.Since(start), err != nil)
+	if err != nil {
+		logger.AppLog.LogError("run failed. reason: %v\n", err)
+	} else {
+		logger.AppLog.LogInfo("run passed in %v\n", time.Since(start))
+	}
+	d.ready.Store(true)
+}
+
+// Run serves the handler and runs the checks right away and then every schedule until ctx is done. Checks run
+// in the background so a signal or a server error is handled while a run is in progress, a tick that comes
+// before the previous run finished is skipped.
+func (d *Daemon) Run(ctx context.Context) error {
+	if d.schedule <= 0 {
+		return ErrInvalidSchedule
+	}
+	listener, err := net.Listen("tcp", d.addr)
+	if err != nil {
+		return err
+	}
+	server := &http.Server{Handler: d.Handler(), ReadHeaderTimeout: 10 * time.Second}
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.Serve(listener)
+	}()
+	logger.AppLog.LogInfo("serving metrics and health on %s, running checks every %v\n", listener.Addr(), d.schedule)
+
+	finished := make(chan struct{}, 1)
+	start := func() {
+		go func() {
+			d.runOnce()
+			finished <- struct{}{}
+		}()
+	}
+	ticker := time.NewTicker(d.schedule)
+	defer ticker.Stop()
+	running := true
+	start()
+	for {
+		select {
+		case <-ticker.C:
+			if running {
+				logger.AppLog.LogWarning("previous run still in progress, skipping this one")
+				continue
+			}
+			running = true
+			start()
+		case <-finished:
+			running = false
+		case err := <-serveErr:
+			return err
+		case <-ctx.Done():
+			if running {
+				logger.AppLog.LogWarning("stopping with a run in progress")
+			}
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+			defer cancel()
+			return server.Shutdown(shutdownCtx)
+		}
+	}
+}
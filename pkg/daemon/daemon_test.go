@@ -0,0 +1,100 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/vprashar2929/integration-test/pkg/logger"
+)
+
+func TestHandlerReadiness(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	d := New(":0", time.Minute, func() error { return errors.New("deployment not ready") })
+	srv := httptest.NewServer(d.Handler())
+	defer srv.Close()
+
+	for path, expected := range map[string]int{"/healthz": http.StatusOK, "/readyz": http.StatusServiceUnavailable, "/metrics": http.StatusOK} {
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatalf("expected nil, got: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != expected {
+			t.Fatalf("expected %d for %s, got: %d", expected, path, resp.StatusCode)
+		}
+	}
+	d.runOnce()
+	resp, err := http.Get(srv.URL + "/readyz")
+	if err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected ready after the first run, got: %d", resp.StatusCode)
+	}
+}
+
+func TestRun(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	var count atomic.Int32
+	d := New("127.0.0.1:0", 50*time.Millisecond, func() error {
+		count.Add(1)
+		return nil
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 180*time.Millisecond)
+	defer cancel()
+	if err := d.Run(ctx); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	if count.Load() < 2 {
+		t.Fatalf("expected at least 2 runs, got: %d", count.Load())
+	}
+}
+
+func TestRunInvalidSchedule(t *testing.T) {
+	if err := New(":0", 0, func() error { return nil }).Run(context.TODO()); err != ErrInvalidSchedule {
+		t.Fatalf("expected ErrInvalidSchedule, got: %v", err)
+	}
+}
+
+func TestRunListenError(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	defer listener.Close()
+	var count atomic.Int32
+	err = New(listener.Addr().String(), time.Minute, func() error {
+		count.Add(1)
+		return nil
+	}).Run(context.TODO())
+	if err == nil || count.Load() != 0 {
+		t.Fatalf("expected a listen error before any run, got: %v after %d runs", err, count.Load())
+	}
+}
+
+func TestRunStopsDuringRun(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	block := make(chan struct{})
+	defer close(block)
+	d := New("127.0.0.1:0", time.Minute, func() error {
+		<-block
+		return nil
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := d.Run(ctx); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Fatalf("expected to stop without waiting for the run, took %v", time.Since(start))
+	}
+}
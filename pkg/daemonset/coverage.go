@@ -0,0 +1,228 @@
+package daemonset
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/vprashar2929/integration-test/pkg/logger"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/client-go/kubernetes"
+)
+
+var (
+	ErrListingNodes        = errors.New("error listing nodes in cluster")
+	ErrListingPods         = errors.New("error listing daemonset pods in namespace")
+	ErrNodeCoverage        = errors.New("daemonset does not cover all eligible nodes")
+	ErrInvalidNodeAffinity = errors.New("daemonset has an invalid node affinity")
+)
+
+// The daemonset controller adds these tolerations to every daemon pod, so taints with
+// these keys never keep a node from running the daemonset.
+var defaultTolerations = []corev1.Toleration{
+	{Key: corev1.TaintNodeNotReady, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoExecute},
+	{Key: corev1.TaintNodeUnreachable, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoExecute},
+	{Key: corev1.TaintNodeDiskPressure, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+	{Key: corev1.TaintNodeMemoryPressure, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+	{Key: corev1.TaintNodePIDPressure, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+	{Key: corev1.TaintNodeUnschedulable, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+}
+
+// NodeCoverage lists the nodes that should run a daemon pod but don't, or run an unhealthy one
+type NodeCoverage struct {
+	Eligible  []string
+	Missing   []string
+	Unhealthy []string
+}
+
+func toleratesTaints(podSpec corev1.PodSpec, taints []corev1.Taint) bool {
+	tolerations := append(append([]corev1.Toleration{}, podSpec.Tolerations...), defaultTolerations...)
+	if podSpec.HostNetwork {
+		tolerations = append(tolerations, corev1.Toleration{Key: corev1.TaintNodeNetworkUnavailable, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule})
+	}
+	for i := range taints {
+		taint := &taints[i]
+		if taint.Effect == corev1.TaintEffectPreferNoSchedule {
+			continue
+		}
+		tolerated := false
+		for j := range tolerations {
+			if tolerations[j].ToleratesTaint(taint) {
+				tolerated = true
+				break
+			}
+		}
+		if !tolerated {
+			return false
+		}
+	}
+	return true
+}
+
+func nodeSelectorRequirementsAsSelector(requirements []corev1.NodeSelectorRequirement) (labels.Selector, error) {
+	selector := labels.NewSelector()
+	for _, expression := range requirements {
+		var op selection.Operator
+		switch expression.Operator {
+		case corev1.NodeSelectorOpIn:
+			op = selection.In
+		case corev1.NodeSelectorOpNotIn:
+			op = selection.NotIn
+		case corev1.NodeSelectorOpExists:
+			op = selection.Exists
+		case corev1.NodeSelectorOpDoesNotExist:
+			op = selection.DoesNotExist
+		case corev1.NodeSelectorOpGt:
+			op = selection.GreaterThan
+		case corev1.NodeSelectorOpLt:
+			op = selection.LessThan
+		default:
+			return nil, fmt.Errorf("%w: unknown operator %s", ErrInvalidNodeAffinity, expression.Operator)
+		}
+		requirement, err := labels.NewRequirement(expression.Key, op, expression.Values)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidNodeAffinity, err)
+		}
+		selector = selector.Add(*requirement)
+	}
+	return selector, nil
+}
+
+func matchesNodeSelectorTerm(node *corev1.Node, term corev1.NodeSelectorTerm) (bool, error) {
+	if len(term.MatchExpressions) == 0 && len(term.MatchFields) == 0 {
+		return false, nil
+	}
+	selector, err := nodeSelectorRequirementsAsSelector(term.MatchExpressions)
+	if err != nil {
+		return false, err
+	}
+	if !selector.Matches(labels.Set(node.Labels)) {
+		return false, nil
+	}
+	for _, field := range term.MatchFields {
+		if field.Key != "metadata.name" {
+			return false, fmt.Errorf("%w: unsupported field %s", ErrInvalidNodeAffinity, field.Key)
+		}
+		matched := false
+		for _, value := range field.Values {
+			if value == node.Name {
+				matched = true
+			}
+		}
+		if (field.Operator == corev1.NodeSelectorOpIn) != matched {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// shouldRunOnNode mirrors the scheduling predicates the daemonset controller uses: nodeSelector,
+// required node affinity and taints against tolerations.
+func shouldRunOnNode(podSpec corev1.PodSpec, node *corev1.Node) (bool, error) {
+	if !labels.SelectorFromSet(podSpec.NodeSelector).Matches(labels.Set(node.Labels)) {
+		return false, nil
+	}
+	if podSpec.Affinity != nil && podSpec.Affinity.NodeAffinity != nil &&
+		podSpec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution != nil {
+		matched := false
+		for _, term := range podSpec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms {
+			ok, err := matchesNodeSelectorTerm(node, term)
+			if err != nil {
+				return false, err
+			}
+			if ok {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false, nil
+		}
+	}
+	return toleratesTaints(podSpec, node.Spec.Taints), nil
+}
+
+func isPodHealthy(pod *corev1.Pod) bool {
+	if pod.Status.Phase != corev1.PodRunning {
+		return false
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+func getNodeCoverage(daemonset *appsv1.DaemonSet, clientset kubernetes.Interface) (*NodeCoverage, error) {
+	nodeList, err := clientset.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		logger.AppLog.LogError("cannot list nodes, err: %v\n", err)
+		return nil, ErrListingNodes
+	}
+	selector, err := metav1.LabelSelectorAsSelector(daemonset.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+	podList, err := clientset.CoreV1().Pods(daemonset.Namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		logger.AppLog.LogError("cannot list pods for daemonset %s, err: %v\n", daemonset.Name, err)
+		return nil, ErrListingPods
+	}
+	podsByNode := make(map[string][]*corev1.Pod)
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if pod.DeletionTimestamp != nil || !metav1.IsControlledBy(pod, daemonset) {
+			continue
+		}
+		podsByNode[pod.Spec.NodeName] = append(podsByNode[pod.Spec.NodeName], pod)
+	}
+
+	coverage := &NodeCoverage{}
+	for i := range nodeList.Items {
+		node := &nodeList.Items[i]
+		eligible, err := shouldRunOnNode(daemonset.Spec.Template.Spec, node)
+		if err != nil {
+			return nil, err
+		}
+		if !eligible {
+			if len(podsByNode[node.Name]) > 0 {
+				logger.AppLog.LogWarning("daemonset %s has a pod on node %s which it should not run on\n", daemonset.Name, node.Name)
+			}
+			continue
+		}
+		coverage.Eligible = append(coverage.Eligible, node.Name)
+		pods := podsByNode[node.Name]
+		if len(pods) == 0 {
+			coverage.Missing = append(coverage.Missing, node.Name)
+			continue
+		}
+		for _, pod := range pods {
+			if !isPodHealthy(pod) {
+				coverage.Unhealthy = append(coverage.Unhealthy, node.Name)
+				break
+			}
+		}
+	}
+	sort.Strings(coverage.Eligible)
+	sort.Strings(coverage.Missing)
+	sort.Strings(coverage.Unhealthy)
+	return coverage, nil
+}
+
+func checkNodeCoverage(daemonset *appsv1.DaemonSet, clientset kubernetes.Interface) error {
+	coverage, err := getNodeCoverage(daemonset, clientset)
+	if err != nil {
+		return err
+	}
+	if len(coverage.Missing) > 0 || len(coverage.Unhealthy) > 0 {
+		return fmt.Errorf("%w: nodes missing a pod: %v, nodes with unhealthy pod: %v", ErrNodeCoverage, coverage.Missing, coverage.Unhealthy)
+	}
+	logger.AppLog.LogDebug("daemonset %s covers all %d eligible nodes\n", daemonset.Name, len(coverage.Eligible))
+	return nil
+}
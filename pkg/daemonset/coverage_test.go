@@ -0,0 +1,145 @@
+package daemonset
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/vprashar2929/integration-test/pkg/logger"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var testCoverageLabels = map[string]string{"app": "node-agent"}
+
+func newCoverageDaemonSet() *appsv1.DaemonSet {
+	return &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testDaemonSet,
+			Namespace: testNS,
+			UID:       types.UID("test-daemonset-uid"),
+		},
+		Spec: appsv1.DaemonSetSpec{
+			Selector: &metav1.LabelSelector{
+				MatchLabels: testCoverageLabels,
+			},
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					NodeSelector: map[string]string{"kubernetes.io/os": "linux"},
+					Tolerations: []corev1.Toleration{
+						{Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "infra", Effect: corev1.TaintEffectNoSchedule},
+					},
+					Affinity: &corev1.Affinity{
+						NodeAffinity: &corev1.NodeAffinity{
+							RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+								NodeSelectorTerms: []corev1.NodeSelectorTerm{
+									{
+										MatchExpressions: []corev1.NodeSelectorRequirement{
+											{Key: "node-role.kubernetes.io/edge", Operator: corev1.NodeSelectorOpDoesNotExist},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func newCoverageNode(name string, nodeLabels map[string]string, taints ...corev1.Taint) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: nodeLabels,
+		},
+		Spec: corev1.NodeSpec{
+			Taints: taints,
+		},
+	}
+}
+
+func newCoveragePod(daemonset *appsv1.DaemonSet, name, nodeName string, ready corev1.ConditionStatus) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       testNS,
+			Labels:          testCoverageLabels,
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(daemonset, appsv1.SchemeGroupVersion.WithKind("DaemonSet"))},
+		},
+		Spec: corev1.PodSpec{
+			NodeName: nodeName,
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			Conditions: []corev1.PodCondition{
+				{Type: corev1.PodReady, Status: ready},
+			},
+		},
+	}
+}
+
+func newCoverageObjects(daemonset *appsv1.DaemonSet, pods ...runtime.Object) []runtime.Object {
+	linux := map[string]string{"kubernetes.io/os": "linux"}
+	objects := []runtime.Object{
+		daemonset,
+		newCoverageNode("node-a", linux),
+		newCoverageNode("node-b", linux, corev1.Taint{Key: "gpu", Effect: corev1.TaintEffectNoSchedule}),
+		newCoverageNode("node-c", linux, corev1.Taint{Key: "dedicated", Value: "infra", Effect: corev1.TaintEffectNoSchedule}),
+		newCoverageNode("node-d", map[string]string{"kubernetes.io/os": "windows"}),
+		newCoverageNode("node-e", map[string]string{"kubernetes.io/os": "linux", "node-role.kubernetes.io/edge": ""}),
+		newCoverageNode("node-f", linux, corev1.Taint{Key: corev1.TaintNodeUnschedulable, Effect: corev1.TaintEffectNoSchedule}),
+	}
+	return append(objects, pods...)
+}
+
+func TestGetNodeCoverage(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	ds := newCoverageDaemonSet()
+	clientset := fake.NewSimpleClientset(newCoverageObjects(ds,
+		newCoveragePod(ds, "agent-a", "node-a", corev1.ConditionTrue),
+		newCoveragePod(ds, "agent-f", "node-f", corev1.ConditionFalse),
+	)...)
+	coverage, err := getNodeCoverage(ds, clientset)
+	if err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	if !reflect.DeepEqual(coverage.Eligible, []string{"node-a", "node-c", "node-f"}) {
+		t.Errorf("expected eligible nodes [node-a node-c node-f], got: %v", coverage.Eligible)
+	}
+	if !reflect.DeepEqual(coverage.Missing, []string{"node-c"}) {
+		t.Errorf("expected missing nodes [node-c], got: %v", coverage.Missing)
+	}
+	if !reflect.DeepEqual(coverage.Unhealthy, []string{"node-f"}) {
+		t.Errorf("expected unhealthy nodes [node-f], got: %v", coverage.Unhealthy)
+	}
+}
+
+func TestCheckNodeCoverage(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	ds := newCoverageDaemonSet()
+	clientset := fake.NewSimpleClientset(newCoverageObjects(ds,
+		newCoveragePod(ds, "agent-a", "node-a", corev1.ConditionTrue),
+		newCoveragePod(ds, "agent-c", "node-c", corev1.ConditionTrue),
+		newCoveragePod(ds, "agent-f", "node-f", corev1.ConditionTrue),
+	)...)
+	if err := checkNodeCoverage(ds, clientset); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+}
+
+func TestCheckNodeCoverageMissingNode(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	ds := newCoverageDaemonSet()
+	clientset := fake.NewSimpleClientset(newCoverageObjects(ds,
+		newCoveragePod(ds, "agent-a", "node-a", corev1.ConditionTrue),
+	)...)
+	if err := checkNodeCoverage(ds, clientset); !errors.Is(err, ErrNodeCoverage) {
+		t.Fatalf("expected ErrNodeCoverage, got: %v", err)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/vprashar2929/integration-test/pkg/logger"
+	"github.com/vprashar2929/integration-test/pkg/metrics"
 	"github.com/vprashar2929/integration-test/pkg/pod"
 
 	appsv1 "k8s.io/api/apps/v1"
@@ -79,9 +80,12 @@ func checkDaemonSetsStatus(namespace string, daemonset appsv1.DaemonSet, clients
 		if err != nil {
 			return err
 		}
-		if updatedDaemonSet.Status.DesiredNumberScheduled == daemonset.Status.NumberAvailable &&
-			updatedDaemonSet.Status.DesiredNumberScheduled == daemonset.Status.CurrentNumberScheduled &&
-			updatedDaemonSet.Status.NumberUnavailable < 0 {
+		if updatedDaemonSet.Status.DesiredNumberScheduled == updatedDaemonSet.Status.NumberAvailable &&
+			updatedDaemonSet.Status.DesiredNumberScheduled == updatedDaemonSet.Status.CurrentNumberScheduled &&
+			updatedDaemonSet.Status.NumberUnavailable == 0 {
+			if err = checkNodeCoverage(updatedDaemonSet, clientset); err != nil {
+				return err
+			}
 			logger.AppLog.LogInfo("daemonset %s is available in namespace %s\n", daemonset.Name, namespace)
 			return nil
 		}
@@ -111,7 +115,9 @@ func validateDaemonSetsByNamespace(namespaces []string, daemonsetsByNamespace ma
 				time.Sleep(interval)
 			}
 			if time.Now().After(deadline) {
-				return fmt.Errorf("timeout checking daemonset status for %s in namespace %s, error: %v", daemonset.Name, namespace, err)
+				err = fmt.Errorf("timeout checking daemonset status for %s in namespace %s, error: %v", daemonset.Name, namespace, err)
+				metrics.ObserveObject("DaemonSet", namespace, daemonset.Name, err)
+				return err
 			}
 			// check pod status
 			deadline = time.Now().Add(timeout)
@@ -122,8 +128,11 @@ func validateDaemonSetsByNamespace(namespaces []string, daemonsetsByNamespace ma
 				time.Sleep(interval)
 			}
 			if time.Now().After(deadline) {
-				return fmt.Errorf("timeout checking pod status for daemonset %s in namespace %s, error: %v", daemonset.Name, namespace, err)
+				err = fmt.Errorf("timeout checking pod status for daemonset %s in namespace %s, error: %v", daemonset.Name, namespace, err)
+				metrics.ObserveObject("DaemonSet", namespace, daemonset.Name, err)
+				return err
 			}
+			metrics.ObserveObject("DaemonSet", namespace, daemonset.Name, nil)
 		}
 	}
 	return nil
@@ -8,9 +8,9 @@ import (
 	"errors"
 
 	"github.com/vprashar2929/integration-test/pkg/logger"
+	"github.com/vprashar2929/integration-test/pkg/metrics"
 	"github.com/vprashar2929/integration-test/pkg/pod"
 	appsv1 "k8s.io/api/apps/v1"
-	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -83,19 +83,22 @@ func checkDeploymentStatus(namespace string, deployment appsv1.Deployment, clien
 			return err
 		}
 
-		if updatedDeployment.Status.UpdatedReplicas == *deployment.Spec.Replicas &&
-			updatedDeployment.Status.Replicas == *deployment.Spec.Replicas &&
-			updatedDeployment.Status.AvailableReplicas == *deployment.Spec.Replicas &&
-			updatedDeployment.Status.ObservedGeneration >= deployment.Generation {
-			return nil
+		rollout, err := getRolloutStatus(updatedDeployment, deployment.Annotations[revisionAnnotation], clientset)
+		if err != nil {
+			return err
 		}
+		logger.AppLog.LogInfo("deployment %s in namespace %s rollout of revision %s is %s: %d%% %s\n",
+			deployment.Name, namespace, rollout.Revision, rollout.State, rollout.Progress, rollout.Message)
 
-		for _, condition := range updatedDeployment.Status.Conditions {
-			if condition.Type == appsv1.DeploymentAvailable && condition.Status == corev1.ConditionFalse {
-				return ErrDeploymentUnhealthy
-			}
+		switch rollout.State {
+		case RolloutComplete:
+			return nil
+		case RolloutStalled:
+			return ErrRolloutStalled
+		case RolloutRolledBack:
+			return ErrRolloutRolledBack
 		}
-		return nil
+		return ErrDeploymentUnhealthy
 	})
 }
 
@@ -113,10 +116,18 @@ func validateDeploymentsByNamespace(namespaces []string, deploymentsByNamespace
 				if err = checkDeploymentStatus(namespace, deployment, clientset); err == nil {
 					break
 				}
+				// a stalled or rolled back rollout will not recover by waiting
+				if errors.Is(err, ErrRolloutStalled) || errors.Is(err, ErrRolloutRolledBack) {
+					err = fmt.Errorf("rollout failed for deployment %s in namespace %s, error: %w", deployment.Name, namespace, err)
+					metrics.ObserveObject("Deployment", namespace, deployment.Name, err)
+					return err
+				}
 				time.Sleep(interval)
 			}
 			if time.Now().After(deadline) {
-				return fmt.Errorf("timeout checking deployment status for %s in namespace %s, error: %v ", deployment.Name, namespace, err)
+				err = fmt.Errorf("timeout checking deployment status for %s in namespace %s, error: %v ", deployment.Name, namespace, err)
+				metrics.ObserveObject("Deployment", namespace, deployment.Name, err)
+				return err
 			}
 
 			// check pod status
@@ -128,9 +139,11 @@ func validateDeploymentsByNamespace(namespaces []string, deploymentsByNamespace
 				time.Sleep(interval)
 			}
 			if time.Now().After(deadline) {
-				return fmt.Errorf("timeout checking pod status for deployment %s in namespace %s, error: %v", deployment.Name, namespace, err)
+				err = fmt.Errorf("timeout checking pod status for deployment %s in namespace %s, error: %v", deployment.Name, namespace, err)
+				metrics.ObserveObject("Deployment", namespace, deployment.Name, err)
+				return err
 			}
-
+			metrics.ObserveObject("Deployment", namespace, deployment.Name, nil)
 		}
 	}
 
@@ -156,3 +169,19 @@ func CheckDeployments(namespaces []string, clientset kubernetes.Interface, inter
 	logger.AppLog.LogInfo("End Deployment validation")
 	return nil
 }
+
+// CheckNamedDeployments validates the given deployments of a namespace, for callers such as operator or
+// release checks that know which deployments they own.
+func CheckNamedDeployments(namespace string, names []string, clientset kubernetes.Interface, interval, timeout time.Duration) error {
+	var deployments []appsv1.Deployment
+	for _, name := range names {
+		deployment, err := clientset.AppsV1().Deployments(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			logger.AppLog.LogError("cannot get deployment %s in namespace %s: %v\n", name, namespace, err)
+			return fmt.Errorf("%w: %s", ErrNoDeployment, name)
+		}
+		deployments = append(deployments, *deployment)
+	}
+	deploymentsByNamespace := map[string][]appsv1.Deployment{namespace: deployments}
+	return validateDeploymentsByNamespace([]string{namespace}, deploymentsByNamespace, clientset, interval, timeout)
+}
@@ -1,6 +1,7 @@
 package deployment
 
 import (
+	"errors"
 	"testing"
 	"time"
 
@@ -319,3 +320,32 @@ func TestCheckDeploymentsNoNamespace(t *testing.T) {
 		t.Fatalf("expected ErrNoNamespace, got: %v", err)
 	}
 }
+
+func TestCheckNamedDeployments(t *testing.T) {
+	testLabels["app"] = "test-app"
+	testPods := corev1.PodList{
+		Items: []corev1.Pod{
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "testPod",
+					Namespace: testNS,
+					Labels:    testLabels,
+				},
+				Status: corev1.PodStatus{
+					Phase: corev1.PodRunning,
+				},
+			},
+		},
+	}
+	clientset := fake.NewSimpleClientset(&testDepList, &testPods)
+	retryer = &mockRetryer{err: nil}
+	logger.NewLogger(logger.LevelInfo)
+	interval := 1 * time.Second
+	timeout := 5 * time.Second
+	if err := CheckNamedDeployments(testNS, []string{testDep}, clientset, interval, timeout); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	if err := CheckNamedDeployments(testNS, []string{"missing"}, clientset, interval, timeout); !errors.Is(err, ErrNoDeployment) {
+		t.Fatalf("expected ErrNoDeployment, got: %v", err)
+	}
+}
@@ -0,0 +1,146 @@
+package deployment
+
+import (
+	"context"
+	"errors"
+
+	"github.com/vprashar2929/integration-test/pkg/hpa"
+	"github.com/vprashar2929/integration-test/pkg/logger"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	revisionAnnotation        = "deployment.kubernetes.io/revision"
+	revisionHistoryAnnotation = "deployment.kubernetes.io/revision-history"
+	progressDeadlineExceeded  = "ProgressDeadlineExceeded"
+)
+
+type RolloutState string
+
+const (
+	RolloutComplete    RolloutState = "Complete"
+	RolloutProgressing RolloutState = "Progressing"
+	RolloutStalled     RolloutState = "Stalled"
+	RolloutRolledBack  RolloutState = "RolledBack"
+)
+
+var (
+	ErrRolloutStalled    = errors.New("deployment rollout exceeded its progress deadline")
+	ErrRolloutRolledBack = errors.New("deployment rollout was rolled back")
+	ErrListingReplicaSet = errors.New("error listing replicasets for deployment")
+)
+
+// RolloutStatus describes where a deployment is in its rollout of the newest ReplicaSet.
+type RolloutStatus struct {
+	State    RolloutState
+	Revision string
+	Progress int32
+	Message  string
+}
+
+func desiredReplicas(deployment *appsv1.Deployment) int32 {
+	if deployment.Spec.Replicas == nil {
+		return 1
+	}
+	return *deployment.Spec.Replicas
+}
+
+// getNewReplicaSet returns the ReplicaSet controlled by the deployment whose revision matches the deployment's revision
+func getNewReplicaSet(deployment *appsv1.Deployment, clientset kubernetes.Interface) (*appsv1.ReplicaSet, error) {
+	revision := deployment.Annotations[revisionAnnotation]
+	if revision == "" {
+		return nil, nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+	replicaSetList, err := clientset.AppsV1().ReplicaSets(deployment.Namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		logger.AppLog.LogError("cannot list replicasets for deployment %s, err: %v\n", deployment.Name, err)
+		return nil, ErrListingReplicaSet
+	}
+	for i := range replicaSetList.Items {
+		replicaSet := &replicaSetList.Items[i]
+		if !metav1.IsControlledBy(replicaSet, deployment) {
+			continue
+		}
+		if replicaSet.Annotations[revisionAnnotation] == revision {
+			return replicaSet, nil
+		}
+	}
+	return nil, nil
+}
+
+// getRolloutStatus compares the live deployment against its own spec and reports the state of the rollout.
+// initialRevision is the revision observed when validation started and is used to detect rollbacks.
+func getRolloutStatus(deployment *appsv1.Deployment, initialRevision string, clientset kubernetes.Interface) (*RolloutStatus, error) {
+	replicas := desiredReplicas(deployment)
+	// an autoscaler owns spec.replicas, the deployment is re-read on every attempt so changes made while waiting
+	// are picked up, and a spec.replicas beyond the autoscaler maximum is about to be scaled down
+	_, maxReplicas, managed, err := hpa.GetReplicaBounds(deployment.Namespace, "Deployment", deployment.Name, clientset)
+	if err != nil {
+		logger.AppLog.LogDebug("cannot look up autoscaler for deployment %s, err: %v\n", deployment.Name, err)
+	}
+	if managed && replicas > maxReplicas {
+		replicas = maxReplicas
+	}
+	status := &RolloutStatus{
+		State:    RolloutProgressing,
+		Revision: deployment.Annotations[revisionAnnotation],
+	}
+
+	newReplicaSet, err := getNewReplicaSet(deployment, clientset)
+	if err != nil {
+		return nil, err
+	}
+	available := deployment.Status.AvailableReplicas
+	if newReplicaSet != nil {
+		available = newReplicaSet.Status.AvailableReplicas
+	}
+	if replicas == 0 || available >= replicas {
+		status.Progress = 100
+	} else {
+		status.Progress = available * 100 / replicas
+	}
+
+	if newReplicaSet != nil && initialRevision != "" && status.Revision != initialRevision &&
+		newReplicaSet.Annotations[revisionHistoryAnnotation] != "" {
+		status.State = RolloutRolledBack
+		status.Message = "revision " + initialRevision + " was rolled back to replicaset " + newReplicaSet.Name
+		return status, nil
+	}
+
+	for _, condition := range deployment.Status.Conditions {
+		if condition.Type == appsv1.DeploymentProgressing && condition.Status == corev1.ConditionFalse &&
+			condition.Reason == progressDeadlineExceeded {
+			status.State = RolloutStalled
+			status.Message = condition.Message
+			return status, nil
+		}
+	}
+
+	if deployment.Status.ObservedGeneration < deployment.Generation {
+		status.Message = "waiting for deployment spec update to be observed"
+		return status, nil
+	}
+	if deployment.Status.UpdatedReplicas < replicas {
+		status.Message = "waiting for updated replicas to be created"
+		return status, nil
+	}
+	if deployment.Status.Replicas > deployment.Status.UpdatedReplicas {
+		status.Message = "waiting for old replicas to be terminated"
+		return status, nil
+	}
+	if (managed && deployment.Status.AvailableReplicas < replicas) ||
+		(!managed && deployment.Status.AvailableReplicas < deployment.Status.UpdatedReplicas) {
+		status.Message = "waiting for updated replicas to become available"
+		return status, nil
+	}
+	status.State = RolloutComplete
+	status.Progress = 100
+	return status, nil
+}
@@ -0,0 +1,211 @@
+package deployment
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vprashar2929/integration-test/pkg/logger"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var testRolloutLabels = map[string]string{"app": "rollout-app"}
+
+func newRolloutDeployment(revision string, replicas int32, status appsv1.DeploymentStatus) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        testDep,
+			Namespace:   testNS,
+			UID:         types.UID("test-deployment-uid"),
+			Generation:  2,
+			Annotations: map[string]string{revisionAnnotation: revision},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: testRolloutLabels,
+			},
+		},
+		Status: status,
+	}
+}
+
+func newRolloutReplicaSet(deployment *appsv1.Deployment, name, revision string, available int32, annotations map[string]string) *appsv1.ReplicaSet {
+	isController := true
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[revisionAnnotation] = revision
+	return &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   testNS,
+			Labels:      testRolloutLabels,
+			Annotations: annotations,
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: "apps/v1",
+					Kind:       "Deployment",
+					Name:       deployment.Name,
+					UID:        deployment.UID,
+					Controller: &isController,
+				},
+			},
+		},
+		Status: appsv1.ReplicaSetStatus{
+			AvailableReplicas: available,
+		},
+	}
+}
+
+func TestGetRolloutStatusComplete(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	dep := newRolloutDeployment("2", 2, appsv1.DeploymentStatus{
+		ObservedGeneration: 2,
+		Replicas:           2,
+		UpdatedReplicas:    2,
+		AvailableReplicas:  2,
+	})
+	rs := newRolloutReplicaSet(dep, "test-deployment-2", "2", 2, nil)
+	clientset := fake.NewSimpleClientset(dep, rs)
+	status, err := getRolloutStatus(dep, "2", clientset)
+	if err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	if status.State != RolloutComplete || status.Progress != 100 || status.Revision != "2" {
+		t.Fatalf("expected complete rollout of revision 2 at 100%%, got: %+v", status)
+	}
+}
+
+func TestGetRolloutStatusProgressing(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	dep := newRolloutDeployment("3", 4, appsv1.DeploymentStatus{
+		ObservedGeneration: 2,
+		Replicas:           5,
+		UpdatedReplicas:    4,
+		AvailableReplicas:  4,
+	})
+	oldRS := newRolloutReplicaSet(dep, "test-deployment-2", "2", 3, nil)
+	newRS := newRolloutReplicaSet(dep, "test-deployment-3", "3", 1, nil)
+	clientset := fake.NewSimpleClientset(dep, oldRS, newRS)
+	status, err := getRolloutStatus(dep, "3", clientset)
+	if err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	if status.State != RolloutProgressing {
+		t.Fatalf("expected progressing rollout, got: %v", status.State)
+	}
+	if status.Progress != 25 {
+		t.Errorf("expected 25%% progress, got: %d", status.Progress)
+	}
+}
+
+func TestGetRolloutStatusStalled(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	dep := newRolloutDeployment("3", 2, appsv1.DeploymentStatus{
+		ObservedGeneration: 2,
+		Replicas:           2,
+		UpdatedReplicas:    1,
+		Conditions: []appsv1.DeploymentCondition{
+			{
+				Type:    appsv1.DeploymentProgressing,
+				Status:  corev1.ConditionFalse,
+				Reason:  progressDeadlineExceeded,
+				Message: "ReplicaSet has timed out progressing.",
+			},
+		},
+	})
+	clientset := fake.NewSimpleClientset(dep)
+	status, err := getRolloutStatus(dep, "3", clientset)
+	if err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	if status.State != RolloutStalled {
+		t.Fatalf("expected stalled rollout, got: %v", status.State)
+	}
+}
+
+func TestGetRolloutStatusRolledBack(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	dep := newRolloutDeployment("4", 1, appsv1.DeploymentStatus{
+		ObservedGeneration: 2,
+		Replicas:           1,
+		UpdatedReplicas:    1,
+		AvailableReplicas:  1,
+	})
+	rs := newRolloutReplicaSet(dep, "test-deployment-2", "4", 1, map[string]string{revisionHistoryAnnotation: "2"})
+	clientset := fake.NewSimpleClientset(dep, rs)
+	status, err := getRolloutStatus(dep, "3", clientset)
+	if err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	if status.State != RolloutRolledBack {
+		t.Fatalf("expected rolled back rollout, got: %v", status.State)
+	}
+}
+
+func TestValidateDeploymentsByNamespaceRolloutStalled(t *testing.T) {
+	retryer = &mockRetryer{err: ErrRolloutStalled}
+	logger.NewLogger(logger.LevelInfo)
+	dep := newRolloutDeployment("3", 1, appsv1.DeploymentStatus{})
+	clientset := fake.NewSimpleClientset(dep)
+	deploymentsByNamespace := map[string][]appsv1.Deployment{testNS: {*dep}}
+	interval := 1 * time.Second
+	timeout := 5 * time.Minute
+	err := validateDeploymentsByNamespace([]string{testNS}, deploymentsByNamespace, clientset, interval, timeout)
+	if !errors.Is(err, ErrRolloutStalled) {
+		t.Fatalf("expected ErrRolloutStalled, got: %v", err)
+	}
+}
+
+func TestGetRolloutStatusAutoscaled(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	// the autoscaler just raised spec.replicas and the new pods are not available yet
+	dep := newRolloutDeployment("2", 6, appsv1.DeploymentStatus{
+		ObservedGeneration: 2,
+		Replicas:           6,
+		UpdatedReplicas:    6,
+		AvailableReplicas:  3,
+	})
+	rs := newRolloutReplicaSet(dep, "test-deployment-2", "2", 3, nil)
+	status, err := getRolloutStatus(dep, "2", fake.NewSimpleClientset(dep, rs))
+	if err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	if status.State != RolloutProgressing {
+		t.Fatalf("expected progressing rollout without autoscaler, got: %v", status.State)
+	}
+
+	minReplicas := int32(2)
+	autoscaler := &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-hpa", Namespace: testNS},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: testDep},
+			MinReplicas:    &minReplicas,
+			MaxReplicas:    10,
+		},
+	}
+	// the autoscaler asked for 6 replicas, having its minimum available is not enough
+	status, err = getRolloutStatus(dep, "2", fake.NewSimpleClientset(dep, rs, autoscaler))
+	if err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	if status.State != RolloutProgressing || status.Progress != 50 {
+		t.Fatalf("expected progressing rollout at 50%%, got: %+v", status)
+	}
+
+	// the autoscaler maximum was lowered and spec.replicas is about to follow
+	autoscaler.Spec.MaxReplicas = 3
+	status, err = getRolloutStatus(dep, "2", fake.NewSimpleClientset(dep, rs, autoscaler))
+	if err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	if status.State != RolloutComplete {
+		t.Fatalf("expected complete rollout with autoscaler maximum available, got: %+v", status)
+	}
+}
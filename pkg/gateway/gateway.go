@@ -0,0 +1,236 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/vprashar2929/integration-test/pkg/logger"
+	"github.com/vprashar2929/integration-test/pkg/service"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+const group = "gateway.networking.k8s.io"
+
+// Gateway API resources are read through the dynamic client, trying the GA version before v1beta1
+// so clusters with older CRDs installed keep working.
+var versions = []string{"v1", "v1beta1"}
+
+var (
+	ErrListingGateway      = errors.New("error listing gateway api resources in namespace")
+	ErrNoGateway           = errors.New("no gateways or httproutes found in namespace")
+	ErrNoNamespace         = errors.New("no namespace provided")
+	ErrGatewayNotReady     = errors.New("gateway not accepted and programmed")
+	ErrHTTPRouteNotReady   = errors.New("httproute not accepted with resolved refs")
+	ErrBackendNotHealthy   = errors.New("httproute backend service not healthy")
+	ErrInvalidInterval     = errors.New("interval or timeout is invalid")
+	ErrGatewayAPINotServed = errors.New("gateway api not served by cluster")
+)
+
+// resources holds the gateways and httproutes found in a namespace along with the served version
+type resources struct {
+	Version    string
+	Gateways   []unstructured.Unstructured
+	HTTPRoutes []unstructured.Unstructured
+}
+
+func gvr(version, resource string) schema.GroupVersionResource {
+	return schema.GroupVersionResource{Group: group, Version: version, Resource: resource}
+}
+
+func getGatewayResources(namespace string, dynamicClient dynamic.Interface) (*resources, error) {
+	for _, version := range versions {
+		gateways, err := dynamicClient.Resource(gvr(version, "gateways")).Namespace(namespace).List(context.TODO(), metav1.ListOptions{})
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			logger.AppLog.LogError("error listing gateways in namespace %s: %v\n", namespace, err)
+			return nil, ErrListingGateway
+		}
+		routes, err := dynamicClient.Resource(gvr(version, "httproutes")).Namespace(namespace).List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			logger.AppLog.LogError("error listing httproutes in namespace %s: %v\n", namespace, err)
+			return nil, ErrListingGateway
+		}
+		if len(gateways.Items) == 0 && len(routes.Items) == 0 {
+			return nil, ErrNoGateway
+		}
+		return &resources{Version: version, Gateways: gateways.Items, HTTPRoutes: routes.Items}, nil
+	}
+	return nil, ErrGatewayAPINotServed
+}
+
+func storeGatewayResourcesByNamespace(namespaces []string, dynamicClient dynamic.Interface) (map[string]*resources, error) {
+	if len(namespaces) == 0 {
+		return nil, ErrNoNamespace
+	}
+	resourcesByNamespace := make(map[string]*resources)
+	for _, namespace := range namespaces {
+		if namespace == "" {
+			logger.AppLog.LogError("Invalid namespace provided.")
+			continue
+		}
+		found, err := getGatewayResources(namespace, dynamicClient)
+		if err != nil {
+			if errors.Is(err, ErrNoGateway) {
+				continue
+			}
+			return nil, err
+		}
+		resourcesByNamespace[namespace] = found
+	}
+	if len(resourcesByNamespace) == 0 {
+		return nil, ErrNoGateway
+	}
+	return resourcesByNamespace, nil
+}
+
+func hasTrueCondition(conditions []interface{}, conditionType string) bool {
+	for _, condition := range conditions {
+		conditionMap, ok := condition.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if conditionMap["type"] == conditionType {
+			return conditionMap["status"] == "True"
+		}
+	}
+	return false
+}
+
+func checkGatewayStatus(namespace, version string, gateway unstructured.Unstructured, dynamicClient dynamic.Interface) error {
+	updatedGateway, err := dynamicClient.Resource(gvr(version, "gateways")).Namespace(namespace).Get(context.TODO(), gateway.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	conditions, _, _ := unstructured.NestedSlice(updatedGateway.Object, "status", "conditions")
+	if !hasTrueCondition(conditions, "Accepted") || !hasTrueCondition(conditions, "Programmed") {
+		return ErrGatewayNotReady
+	}
+	logger.AppLog.LogInfo("gateway %s is programmed in namespace %s\n", gateway.GetName(), namespace)
+	return nil
+}
+
+// getBackendServices returns namespace/name pairs of every Service referenced by the route's rules
+func getBackendServices(route *unstructured.Unstructured) [][2]string {
+	var services [][2]string
+	rules, _, _ := unstructured.NestedSlice(route.Object, "spec", "rules")
+	for _, rule := range rules {
+		ruleMap, ok := rule.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		backendRefs, _, _ := unstructured.NestedSlice(ruleMap, "backendRefs")
+		for _, ref := range backendRefs {
+			refMap, ok := ref.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			refGroup, _ := refMap["group"].(string)
+			kind, _ := refMap["kind"].(string)
+			if refGroup != "" || (kind != "" && kind != "Service") {
+				continue
+			}
+			name, _ := refMap["name"].(string)
+			namespace, _ := refMap["namespace"].(string)
+			if namespace == "" {
+				namespace = route.GetNamespace()
+			}
+			services = append(services, [2]string{namespace, name})
+		}
+	}
+	return services
+}
+
+func checkHTTPRouteStatus(namespace, version string, route unstructured.Unstructured, dynamicClient dynamic.Interface, clientset kubernetes.Interface) error {
+	updatedRoute, err := dynamicClient.Resource(gvr(version, "httproutes")).Namespace(namespace).Get(context.TODO(), route.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	parents, _, _ := unstructured.NestedSlice(updatedRoute.Object, "status", "parents")
+	if len(parents) == 0 {
+		return ErrHTTPRouteNotReady
+	}
+	for _, parent := range parents {
+		parentMap, ok := parent.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		conditions, _, _ := unstructured.NestedSlice(parentMap, "conditions")
+		if !hasTrueCondition(conditions, "Accepted") || !hasTrueCondition(conditions, "ResolvedRefs") {
+			parentName, _, _ := unstructured.NestedString(parentMap, "parentRef", "name")
+			return fmt.Errorf("%w: parent %s", ErrHTTPRouteNotReady, parentName)
+		}
+	}
+	for _, backend := range getBackendServices(updatedRoute) {
+		if err := service.GetServiceStatus(backend[0], backend[1], clientset); err != nil {
+			return fmt.Errorf("%w: %s/%s: %v", ErrBackendNotHealthy, backend[0], backend[1], err)
+		}
+	}
+	logger.AppLog.LogInfo("httproute %s is accepted in namespace %s\n", route.GetName(), namespace)
+	return nil
+}
+
+func validateGatewayResourcesByNamespace(namespaces []string, resourcesByNamespace map[string]*resources, dynamicClient dynamic.Interface, clientset kubernetes.Interface, interval, timeout time.Duration) error {
+	var err error
+	if interval <= 0 || timeout <= 0 {
+		return ErrInvalidInterval
+	}
+	for _, namespace := range namespaces {
+		found, ok := resourcesByNamespace[namespace]
+		if !ok {
+			continue
+		}
+		for _, gateway := range found.Gateways {
+			deadline := time.Now().Add(timeout)
+			for time.Now().Before(deadline) {
+				if err = checkGatewayStatus(namespace, found.Version, gateway, dynamicClient); err == nil {
+					break
+				}
+				time.Sleep(interval)
+			}
+			if time.Now().After(deadline) {
+				return fmt.Errorf("timeout checking gateway status for %s in namespace %s, error: %v", gateway.GetName(), namespace, err)
+			}
+		}
+		for _, route := range found.HTTPRoutes {
+			deadline := time.Now().Add(timeout)
+			for time.Now().Before(deadline) {
+				if err = checkHTTPRouteStatus(namespace, found.Version, route, dynamicClient, clientset); err == nil {
+					break
+				}
+				time.Sleep(interval)
+			}
+			if time.Now().After(deadline) {
+				return fmt.Errorf("timeout checking httproute status for %s in namespace %s, error: %v", route.GetName(), namespace, err)
+			}
+		}
+	}
+	return nil
+}
+
+func CheckGateways(namespaces []string, dynamicClient dynamic.Interface, clientset kubernetes.Interface, interval, timeout time.Duration) error {
+	logger.AppLog.LogInfo("Begin Gateway API validation")
+
+	resourcesByNamespace, err := storeGatewayResourcesByNamespace(namespaces, dynamicClient)
+	if err != nil {
+		if errors.Is(err, ErrNoGateway) || errors.Is(err, ErrGatewayAPINotServed) {
+			logger.AppLog.LogWarning("No gateways or httproutes found. Skipping validations.")
+			return nil
+		}
+		return err
+	}
+	if err = validateGatewayResourcesByNamespace(namespaces, resourcesByNamespace, dynamicClient, clientset, interval, timeout); err != nil {
+		return err
+	}
+
+	logger.AppLog.LogInfo("End Gateway API validation")
+	return nil
+}
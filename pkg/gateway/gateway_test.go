@@ -0,0 +1,213 @@
+package gateway
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vprashar2929/integration-test/pkg/logger"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var (
+	testNS      = "test-namespace"
+	testSvc     = "test-service"
+	testService = corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testSvc,
+			Namespace: testNS,
+		},
+		Spec: corev1.ServiceSpec{
+			Type:  corev1.ServiceTypeClusterIP,
+			Ports: []corev1.ServicePort{{Name: "http", Port: 8080}},
+		},
+	}
+	testEndpoints = corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testSvc,
+			Namespace: testNS,
+		},
+		Subsets: []corev1.EndpointSubset{
+			{
+				Ports:     []corev1.EndpointPort{{Name: "http", Port: 8080}},
+				Addresses: []corev1.EndpointAddress{{IP: "10.0.0.1"}},
+			},
+		},
+	}
+)
+
+func condition(conditionType, status string) map[string]interface{} {
+	return map[string]interface{}{"type": conditionType, "status": status}
+}
+
+func newTestGateway(version, programmed string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": group + "/" + version,
+			"kind":       "Gateway",
+			"metadata": map[string]interface{}{
+				"name":      "test-gateway",
+				"namespace": testNS,
+			},
+			"status": map[string]interface{}{
+				"conditions": []interface{}{
+					condition("Accepted", "True"),
+					condition("Programmed", programmed),
+				},
+			},
+		},
+	}
+}
+
+func newTestHTTPRoute(version, resolvedRefs, backend string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": group + "/" + version,
+			"kind":       "HTTPRoute",
+			"metadata": map[string]interface{}{
+				"name":      "test-route",
+				"namespace": testNS,
+			},
+			"spec": map[string]interface{}{
+				"rules": []interface{}{
+					map[string]interface{}{
+						"backendRefs": []interface{}{
+							map[string]interface{}{"name": backend, "port": int64(8080)},
+						},
+					},
+				},
+			},
+			"status": map[string]interface{}{
+				"parents": []interface{}{
+					map[string]interface{}{
+						"parentRef": map[string]interface{}{"name": "test-gateway"},
+						"conditions": []interface{}{
+							condition("Accepted", "True"),
+							condition("ResolvedRefs", resolvedRefs),
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func newGatewayClient(t *testing.T, objects ...*unstructured.Unstructured) *dynamicfake.FakeDynamicClient {
+	listKinds := make(map[schema.GroupVersionResource]string)
+	for _, version := range versions {
+		listKinds[gvr(version, "gateways")] = "GatewayList"
+		listKinds[gvr(version, "httproutes")] = "HTTPRouteList"
+	}
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds)
+	// the fake client guesses "gatewaies" from the Gateway kind, so objects are added with an explicit resource
+	for _, object := range objects {
+		resource := "httproutes"
+		if object.GetKind() == "Gateway" {
+			resource = "gateways"
+		}
+		version := object.GroupVersionKind().Version
+		if err := dynamicClient.Tracker().Create(gvr(version, resource), object, object.GetNamespace()); err != nil {
+			t.Fatalf("cannot seed fake dynamic client: %v", err)
+		}
+	}
+	return dynamicClient
+}
+
+func TestGetGatewayResources(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	dynamicClient := newGatewayClient(t, newTestGateway("v1", "True"), newTestHTTPRoute("v1", "True", testSvc))
+	found, err := getGatewayResources(testNS, dynamicClient)
+	if err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	if found.Version != "v1" || len(found.Gateways) != 1 || len(found.HTTPRoutes) != 1 {
+		t.Errorf("expected 1 gateway and 1 httproute at v1, got: %+v", found)
+	}
+}
+
+func TestGetGatewayResourcesFallbackVersion(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	dynamicClient := newGatewayClient(t, newTestGateway("v1beta1", "True"))
+	dynamicClient.PrependReactor("list", "gateways", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetResource().Version == "v1" {
+			return true, nil, apierrors.NewNotFound(action.GetResource().GroupResource(), "")
+		}
+		return false, nil, nil
+	})
+	found, err := getGatewayResources(testNS, dynamicClient)
+	if err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	if found.Version != "v1beta1" {
+		t.Errorf("expected v1beta1, got: %s", found.Version)
+	}
+}
+
+func TestCheckGatewayStatusNotProgrammed(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	gateway := newTestGateway("v1", "False")
+	dynamicClient := newGatewayClient(t, gateway)
+	if err := checkGatewayStatus(testNS, "v1", *gateway, dynamicClient); err != ErrGatewayNotReady {
+		t.Fatalf("expected ErrGatewayNotReady, got: %v", err)
+	}
+}
+
+func TestCheckHTTPRouteStatus(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	route := newTestHTTPRoute("v1", "True", testSvc)
+	dynamicClient := newGatewayClient(t, route)
+	clientset := fake.NewSimpleClientset(&testService, &testEndpoints)
+	if err := checkHTTPRouteStatus(testNS, "v1", *route, dynamicClient, clientset); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+}
+
+func TestCheckHTTPRouteStatusUnresolvedRefs(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	route := newTestHTTPRoute("v1", "False", testSvc)
+	dynamicClient := newGatewayClient(t, route)
+	clientset := fake.NewSimpleClientset(&testService, &testEndpoints)
+	if err := checkHTTPRouteStatus(testNS, "v1", *route, dynamicClient, clientset); !errors.Is(err, ErrHTTPRouteNotReady) {
+		t.Fatalf("expected ErrHTTPRouteNotReady, got: %v", err)
+	}
+}
+
+func TestCheckHTTPRouteStatusMissingBackend(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	route := newTestHTTPRoute("v1", "True", "missing")
+	dynamicClient := newGatewayClient(t, route)
+	clientset := fake.NewSimpleClientset()
+	if err := checkHTTPRouteStatus(testNS, "v1", *route, dynamicClient, clientset); !errors.Is(err, ErrBackendNotHealthy) {
+		t.Fatalf("expected ErrBackendNotHealthy, got: %v", err)
+	}
+}
+
+func TestCheckGateways(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	dynamicClient := newGatewayClient(t, newTestGateway("v1", "True"), newTestHTTPRoute("v1", "True", testSvc))
+	clientset := fake.NewSimpleClientset(&testService, &testEndpoints)
+	interval := 1 * time.Second
+	timeout := 5 * time.Second
+	if err := CheckGateways([]string{testNS}, dynamicClient, clientset, interval, timeout); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+}
+
+func TestCheckGatewaysNoNamespace(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	dynamicClient := newGatewayClient(t)
+	clientset := fake.NewSimpleClientset()
+	interval := 1 * time.Second
+	timeout := 5 * time.Second
+	if err := CheckGateways([]string{}, dynamicClient, clientset, interval, timeout); err != ErrNoNamespace {
+		t.Fatalf("expected ErrNoNamespace, got: %v", err)
+	}
+}
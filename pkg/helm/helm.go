@@ -0,0 +1,291 @@
+package helm
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vprashar2929/integration-test/pkg/deployment"
+	"github.com/vprashar2929/integration-test/pkg/logger"
+	"github.com/vprashar2929/integration-test/pkg/service"
+	"github.com/vprashar2929/integration-test/pkg/statefulset"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Helm stores every revision of a release in a secret of this type, named sh.helm.release.v1.<name>.v<version>
+// and labelled with the owner, release name and version.
+const (
+	releaseSecretType   = "helm.sh/release.v1"
+	releaseSecretPrefix = "sh.helm.release.v1."
+	releaseSelector     = "owner=helm"
+	statusDeployed      = "deployed"
+)
+
+var gzipMagic = []byte{0x1f, 0x8b, 0x08}
+
+var (
+	ErrListingRelease     = errors.New("error listing helm releases in namespace")
+	ErrNoRelease          = errors.New("no helm releases found in namespace")
+	ErrNoNamespace        = errors.New("no namespace provided")
+	ErrDecodingRelease    = errors.New("error decoding helm release")
+	ErrReleaseNotDeployed = errors.New("latest helm release revision not deployed")
+	ErrInvalidInterval    = errors.New("interval or timeout is invalid")
+)
+
+// release holds the fields of a decoded helm release that are needed for validation
+type release struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Version   int    `json:"version"`
+	Info      struct {
+		Status      string `json:"status"`
+		Description string `json:"description"`
+	} `json:"info"`
+	Manifest string `json:"manifest"`
+}
+
+// manifestObject identifies an object rendered by a release
+type manifestObject struct {
+	Kind     string `json:"kind"`
+	Metadata struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"metadata"`
+}
+
+// decodeRelease reverses the helm secret driver encoding: base64 of the gzipped release JSON
+func decodeRelease(data []byte) (*release, error) {
+	decoded, err := base64.StdEncoding.DecodeString(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDecodingRelease, err)
+	}
+	if bytes.HasPrefix(decoded, gzipMagic) {
+		reader, err := gzip.NewReader(bytes.NewReader(decoded))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrDecodingRelease, err)
+		}
+		defer reader.Close()
+		if decoded, err = io.ReadAll(reader); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrDecodingRelease, err)
+		}
+	}
+	var rls release
+	if err := json.Unmarshal(decoded, &rls); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDecodingRelease, err)
+	}
+	return &rls, nil
+}
+
+func secretVersion(secret *corev1.Secret) int {
+	version, err := strconv.Atoi(secret.Labels["version"])
+	if err != nil {
+		return 0
+	}
+	return version
+}
+
+// latestReleaseSecrets returns the secret holding the highest revision of every release
+func latestReleaseSecrets(secrets []corev1.Secret) []corev1.Secret {
+	latest := make(map[string]corev1.Secret)
+	var names []string
+	for _, secret := range secrets {
+		if secret.Type != releaseSecretType || !strings.HasPrefix(secret.Name, releaseSecretPrefix) {
+			continue
+		}
+		name := secret.Labels["name"]
+		current, ok := latest[name]
+		if !ok {
+			names = append(names, name)
+		}
+		if !ok || secretVersion(&secret) > secretVersion(&current) {
+			latest[name] = secret
+		}
+	}
+	releases := make([]corev1.Secret, 0, len(names))
+	for _, name := range names {
+		releases = append(releases, latest[name])
+	}
+	return releases
+}
+
+func getRelease(namespace string, clientset kubernetes.Interface) ([]corev1.Secret, error) {
+	secrets, err := clientset.CoreV1().Secrets(namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: releaseSelector})
+	if err != nil {
+		logger.AppLog.LogError("error listing helm releases in namespace %s: %v\n", namespace, err)
+		return nil, ErrListingRelease
+	}
+	releases := latestReleaseSecrets(secrets.Items)
+	if len(releases) == 0 {
+		return nil, ErrNoRelease
+	}
+	return releases, nil
+}
+
+func storeReleasesByNamespace(namespaces []string, clientset kubernetes.Interface) (map[string][]corev1.Secret, error) {
+	if len(namespaces) == 0 {
+		return nil, ErrNoNamespace
+	}
+	releasesByNamespace := make(map[string][]corev1.Secret)
+	for _, namespace := range namespaces {
+		if namespace == "" {
+			logger.AppLog.LogError("Invalid namespace provided.")
+			continue
+		}
+		releases, err := getRelease(namespace, clientset)
+		if err != nil {
+			if errors.Is(err, ErrNoRelease) {
+				continue
+			}
+			return nil, err
+		}
+		releasesByNamespace[namespace] = releases
+	}
+	if len(releasesByNamespace) == 0 {
+		return nil, ErrNoRelease
+	}
+	return releasesByNamespace, nil
+}
+
+// checkReleaseStatus looks up the latest revision of the release again, as an upgrade may have happened
+// since the releases were listed, and returns it once deployed
+func checkReleaseStatus(namespace string, secret corev1.Secret, clientset kubernetes.Interface) (*release, error) {
+	selector := releaseSelector + ",name=" + secret.Labels["name"]
+	secrets, err := clientset.CoreV1().Secrets(namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, err
+	}
+	latest := latestReleaseSecrets(secrets.Items)
+	if len(latest) == 0 {
+		return nil, ErrNoRelease
+	}
+	rls, err := decodeRelease(latest[0].Data["release"])
+	if err != nil {
+		return nil, err
+	}
+	if rls.Info.Status != statusDeployed {
+		return nil, fmt.Errorf("%w: revision %d is %s %s", ErrReleaseNotDeployed, rls.Version, rls.Info.Status, rls.Info.Description)
+	}
+	logger.AppLog.LogInfo("helm release %s revision %d is deployed in namespace %s\n", rls.Name, rls.Version, namespace)
+	return rls, nil
+}
+
+// getManifestObjects returns the objects rendered in the release manifest, defaulting their namespace to the release's
+func getManifestObjects(rls *release) ([]manifestObject, error) {
+	var objects []manifestObject
+	decoder := yaml.NewYAMLOrJSONDecoder(strings.NewReader(rls.Manifest), 4096)
+	for {
+		var object manifestObject
+		if err := decoder.Decode(&object); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("%w: manifest of %s: %v", ErrDecodingRelease, rls.Name, err)
+		}
+		if object.Kind == "" || object.Metadata.Name == "" {
+			continue
+		}
+		if object.Metadata.Namespace == "" {
+			object.Metadata.Namespace = rls.Namespace
+		}
+		objects = append(objects, object)
+	}
+	return objects, nil
+}
+
+func validateManifestObjects(objects []manifestObject, clientset kubernetes.Interface, interval, timeout time.Duration) error {
+	var err error
+	for _, object := range objects {
+		namespace, name := object.Metadata.Namespace, object.Metadata.Name
+		switch object.Kind {
+		case "Deployment":
+			err = deployment.CheckNamedDeployments(namespace, []string{name}, clientset, interval, timeout)
+		case "StatefulSet":
+			err = statefulset.CheckNamedStatefulSets(namespace, []string{name}, clientset, interval, timeout)
+		case "Service":
+			deadline := time.Now().Add(timeout)
+			for time.Now().Before(deadline) {
+				if err = service.GetServiceStatus(namespace, name, clientset); err == nil {
+					break
+				}
+				time.Sleep(interval)
+			}
+			if time.Now().After(deadline) {
+				err = fmt.Errorf("timeout checking service status for %s in namespace %s, error: %v", name, namespace, err)
+			}
+		default:
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateReleasesByNamespace(namespaces []string, releasesByNamespace map[string][]corev1.Secret, clientset kubernetes.Interface, interval, timeout time.Duration) error {
+	var err error
+	if interval <= 0 || timeout <= 0 {
+		return ErrInvalidInterval
+	}
+	for _, namespace := range namespaces {
+		for _, secret := range releasesByNamespace[namespace] {
+
+			// check release status
+			var rls *release
+			deadline := time.Now().Add(timeout)
+			for time.Now().Before(deadline) {
+				if rls, err = checkReleaseStatus(namespace, secret, clientset); err == nil {
+					break
+				}
+				// a release that cannot be decoded will not fix itself
+				if errors.Is(err, ErrDecodingRelease) {
+					return fmt.Errorf("cannot read helm release %s in namespace %s, error: %w", secret.Labels["name"], namespace, err)
+				}
+				time.Sleep(interval)
+			}
+			if time.Now().After(deadline) {
+				return fmt.Errorf("timeout checking helm release status for %s in namespace %s, error: %v", secret.Labels["name"], namespace, err)
+			}
+
+			// check workloads rendered by the release
+			objects, err := getManifestObjects(rls)
+			if err != nil {
+				return err
+			}
+			if err = validateManifestObjects(objects, clientset, interval, timeout); err != nil {
+				return fmt.Errorf("workloads of helm release %s in namespace %s not healthy, error: %w", rls.Name, namespace, err)
+			}
+		}
+	}
+	return nil
+}
+
+func CheckReleases(namespaces []string, clientset kubernetes.Interface, interval, timeout time.Duration) error {
+	logger.AppLog.LogInfo("Begin Helm release validation")
+
+	releasesByNamespace, err := storeReleasesByNamespace(namespaces, clientset)
+	if err != nil {
+		if errors.Is(err, ErrNoRelease) {
+			logger.AppLog.LogWarning("No helm releases found. Skipping validations.")
+			return nil
+		}
+		return err
+	}
+	if err = validateReleasesByNamespace(namespaces, releasesByNamespace, clientset, interval, timeout); err != nil {
+		return err
+	}
+
+	logger.AppLog.LogInfo("End Helm release validation")
+	return nil
+}
@@ -0,0 +1,182 @@
+package helm
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/vprashar2929/integration-test/pkg/logger"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var (
+	testNS       = "test-namespace"
+	testRelease  = "test-release"
+	testDep      = "test-release-app"
+	testLabels   = map[string]string{"app": "test-release"}
+	testReplicas = int32(1)
+	testManifest = `---
+# Source: app/templates/configmap.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: test-release-config
+---
+# Source: app/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: test-release-app
+spec:
+  replicas: 1
+`
+	testDeployment = appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        testDep,
+			Namespace:   testNS,
+			Generation:  1,
+			Annotations: map[string]string{"deployment.kubernetes.io/revision": "1"},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &testReplicas,
+			Selector: &metav1.LabelSelector{MatchLabels: testLabels},
+		},
+		Status: appsv1.DeploymentStatus{
+			ObservedGeneration: 1,
+			Replicas:           1,
+			UpdatedReplicas:    1,
+			ReadyReplicas:      1,
+			AvailableReplicas:  1,
+		},
+	}
+	testPod = corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-release-app-abcde",
+			Namespace: testNS,
+			Labels:    testLabels,
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+		},
+	}
+)
+
+func newReleaseSecret(t *testing.T, version int, status string) *corev1.Secret {
+	rls := release{Name: testRelease, Namespace: testNS, Version: version, Manifest: testManifest}
+	rls.Info.Status = status
+	data, err := json.Marshal(rls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	writer.Close()
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      releaseSecretPrefix + testRelease + ".v" + strconv.Itoa(version),
+			Namespace: testNS,
+			Labels: map[string]string{
+				"owner":   "helm",
+				"name":    testRelease,
+				"version": strconv.Itoa(version),
+				"status":  status,
+			},
+		},
+		Type: releaseSecretType,
+		Data: map[string][]byte{"release": []byte(base64.StdEncoding.EncodeToString(buf.Bytes()))},
+	}
+}
+
+func TestDecodeRelease(t *testing.T) {
+	secret := newReleaseSecret(t, 1, statusDeployed)
+	rls, err := decodeRelease(secret.Data["release"])
+	if err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	if rls.Name != testRelease || rls.Version != 1 || rls.Info.Status != statusDeployed {
+		t.Errorf("expected deployed revision 1 of %s, got: %+v", testRelease, rls)
+	}
+	if _, err := decodeRelease([]byte("not base64!")); !errors.Is(err, ErrDecodingRelease) {
+		t.Fatalf("expected ErrDecodingRelease, got: %v", err)
+	}
+}
+
+func TestGetReleaseLatestRevision(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	clientset := fake.NewSimpleClientset(newReleaseSecret(t, 1, "superseded"), newReleaseSecret(t, 2, statusDeployed))
+	releases, err := getRelease(testNS, clientset)
+	if err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	if len(releases) != 1 || releases[0].Labels["version"] != "2" {
+		t.Fatalf("expected revision 2 only, got: %v", releases)
+	}
+}
+
+func TestGetReleaseNoRelease(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	if _, err := getRelease(testNS, fake.NewSimpleClientset()); err != ErrNoRelease {
+		t.Fatalf("expected ErrNoRelease, got: %v", err)
+	}
+}
+
+func TestCheckReleaseStatusPendingUpgrade(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	deployed := newReleaseSecret(t, 1, statusDeployed)
+	clientset := fake.NewSimpleClientset(deployed, newReleaseSecret(t, 2, "pending-upgrade"))
+	if _, err := checkReleaseStatus(testNS, *deployed, clientset); !errors.Is(err, ErrReleaseNotDeployed) {
+		t.Fatalf("expected ErrReleaseNotDeployed, got: %v", err)
+	}
+}
+
+func TestGetManifestObjects(t *testing.T) {
+	secret := newReleaseSecret(t, 1, statusDeployed)
+	rls, _ := decodeRelease(secret.Data["release"])
+	objects, err := getManifestObjects(rls)
+	if err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	if len(objects) != 2 || objects[1].Kind != "Deployment" || objects[1].Metadata.Namespace != testNS {
+		t.Fatalf("expected configmap and deployment in %s, got: %+v", testNS, objects)
+	}
+}
+
+func TestCheckReleases(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	clientset := fake.NewSimpleClientset(newReleaseSecret(t, 1, statusDeployed), &testDeployment, &testPod)
+	interval := 1 * time.Second
+	timeout := 5 * time.Second
+	if err := CheckReleases([]string{testNS}, clientset, interval, timeout); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+}
+
+func TestCheckReleasesMissingWorkload(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	clientset := fake.NewSimpleClientset(newReleaseSecret(t, 1, statusDeployed))
+	interval := 1 * time.Second
+	timeout := 2 * time.Second
+	if err := CheckReleases([]string{testNS}, clientset, interval, timeout); err == nil {
+		t.Fatalf("expected error, got: %v", err)
+	}
+}
+
+func TestCheckReleasesNoNamespace(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	interval := 1 * time.Second
+	timeout := 5 * time.Second
+	if err := CheckReleases([]string{}, fake.NewSimpleClientset(), interval, timeout); err != ErrNoNamespace {
+		t.Fatalf("expected ErrNoNamespace, got: %v", err)
+	}
+}
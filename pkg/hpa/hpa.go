@@ -0,0 +1,219 @@
+package hpa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/vprashar2929/integration-test/pkg/logger"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+var (
+	ErrListingHPA          = errors.New("error listing horizontalpodautoscalers in namespace")
+	ErrNoHPA               = errors.New("no horizontalpodautoscalers found in namespace")
+	ErrNoNamespace         = errors.New("no namespace provided")
+	ErrScaleTargetMissing  = errors.New("horizontalpodautoscaler scale target not found")
+	ErrHPANotActive        = errors.New("horizontalpodautoscaler not able to scale")
+	ErrMetricsUnknown      = errors.New("horizontalpodautoscaler metrics unknown")
+	ErrReplicasOutOfBounds = errors.New("horizontalpodautoscaler replicas outside min/max")
+	ErrInvalidInterval     = errors.New("interval or timeout is invalid")
+)
+
+func minReplicas(hpa *autoscalingv2.HorizontalPodAutoscaler) int32 {
+	if hpa.Spec.MinReplicas == nil {
+		return 1
+	}
+	return *hpa.Spec.MinReplicas
+}
+
+// GetReplicaBounds returns the min and max replicas of the autoscaler targeting the given workload, so
+// checkers can tell replica counts set by the autoscaler from unexpected ones
+func GetReplicaBounds(namespace, kind, name string, clientset kubernetes.Interface) (int32, int32, bool, error) {
+	hpaList, err := clientset.AutoscalingV2().HorizontalPodAutoscalers(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return 0, 0, false, err
+	}
+	for i := range hpaList.Items {
+		ref := hpaList.Items[i].Spec.ScaleTargetRef
+		if ref.Kind == kind && ref.Name == name {
+			return minReplicas(&hpaList.Items[i]), hpaList.Items[i].Spec.MaxReplicas, true, nil
+		}
+	}
+	return 0, 0, false, nil
+}
+
+func getHPA(namespace string, clientset kubernetes.Interface) (*autoscalingv2.HorizontalPodAutoscalerList, error) {
+	hpaList, err := clientset.AutoscalingV2().HorizontalPodAutoscalers(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		logger.AppLog.LogError("error listing horizontalpodautoscalers in namespace %s: %v\n", namespace, err)
+		return nil, ErrListingHPA
+	}
+	if len(hpaList.Items) == 0 {
+		return nil, ErrNoHPA
+	}
+	return hpaList, nil
+}
+
+func storeHPAsByNamespace(namespaces []string, clientset kubernetes.Interface) (map[string][]autoscalingv2.HorizontalPodAutoscaler, error) {
+	if len(namespaces) == 0 {
+		return nil, ErrNoNamespace
+	}
+	hpasByNamespace := make(map[string][]autoscalingv2.HorizontalPodAutoscaler)
+	for _, namespace := range namespaces {
+		if namespace == "" {
+			logger.AppLog.LogError("Invalid namespace provided.")
+			continue
+		}
+		hpaList, err := getHPA(namespace, clientset)
+		if err != nil {
+			if errors.Is(err, ErrNoHPA) {
+				continue
+			}
+			return nil, err
+		}
+		hpasByNamespace[namespace] = hpaList.Items
+	}
+	if len(hpasByNamespace) == 0 {
+		return nil, ErrNoHPA
+	}
+	return hpasByNamespace, nil
+}
+
+func checkScaleTarget(namespace string, ref autoscalingv2.CrossVersionObjectReference, clientset kubernetes.Interface) error {
+	var err error
+	switch ref.Kind {
+	case "Deployment":
+		_, err = clientset.AppsV1().Deployments(namespace).Get(context.TODO(), ref.Name, metav1.GetOptions{})
+	case "StatefulSet":
+		_, err = clientset.AppsV1().StatefulSets(namespace).Get(context.TODO(), ref.Name, metav1.GetOptions{})
+	case "ReplicaSet":
+		_, err = clientset.AppsV1().ReplicaSets(namespace).Get(context.TODO(), ref.Name, metav1.GetOptions{})
+	default:
+		logger.AppLog.LogWarning("cannot verify scale target %s %s, kind not supported\n", ref.Kind, ref.Name)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %s %s: %v", ErrScaleTargetMissing, ref.Kind, ref.Name, err)
+	}
+	return nil
+}
+
+func hasTrueCondition(hpa *autoscalingv2.HorizontalPodAutoscaler, conditionType autoscalingv2.HorizontalPodAutoscalerConditionType) (bool, string) {
+	for _, condition := range hpa.Status.Conditions {
+		if condition.Type == conditionType {
+			return condition.Status == corev1.ConditionTrue, condition.Message
+		}
+	}
+	return false, "condition not reported"
+}
+
+// metricValueKnown reports whether the autoscaler was able to read a current value for the metric
+func metricValueKnown(metric autoscalingv2.MetricStatus) bool {
+	var current *autoscalingv2.MetricValueStatus
+	switch metric.Type {
+	case autoscalingv2.ResourceMetricSourceType:
+		if metric.Resource != nil {
+			current = &metric.Resource.Current
+		}
+	case autoscalingv2.ContainerResourceMetricSourceType:
+		if metric.ContainerResource != nil {
+			current = &metric.ContainerResource.Current
+		}
+	case autoscalingv2.PodsMetricSourceType:
+		if metric.Pods != nil {
+			current = &metric.Pods.Current
+		}
+	case autoscalingv2.ObjectMetricSourceType:
+		if metric.Object != nil {
+			current = &metric.Object.Current
+		}
+	case autoscalingv2.ExternalMetricSourceType:
+		if metric.External != nil {
+			current = &metric.External.Current
+		}
+	}
+	return current != nil && (current.Value != nil || current.AverageValue != nil || current.AverageUtilization != nil)
+}
+
+func checkMetrics(hpa *autoscalingv2.HorizontalPodAutoscaler) error {
+	known := 0
+	for _, metric := range hpa.Status.CurrentMetrics {
+		if metricValueKnown(metric) {
+			known++
+		}
+	}
+	if known < len(hpa.Spec.Metrics) {
+		return fmt.Errorf("%w: %d of %d metrics have a current value", ErrMetricsUnknown, known, len(hpa.Spec.Metrics))
+	}
+	return nil
+}
+
+func checkHPAStatus(namespace string, hpa autoscalingv2.HorizontalPodAutoscaler, clientset kubernetes.Interface) error {
+	updatedHPA, err := clientset.AutoscalingV2().HorizontalPodAutoscalers(namespace).Get(context.TODO(), hpa.Name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if err := checkScaleTarget(namespace, updatedHPA.Spec.ScaleTargetRef, clientset); err != nil {
+		return err
+	}
+	for _, conditionType := range []autoscalingv2.HorizontalPodAutoscalerConditionType{autoscalingv2.AbleToScale, autoscalingv2.ScalingActive} {
+		if ok, message := hasTrueCondition(updatedHPA, conditionType); !ok {
+			return fmt.Errorf("%w: %s: %s", ErrHPANotActive, conditionType, message)
+		}
+	}
+	if err := checkMetrics(updatedHPA); err != nil {
+		return err
+	}
+	current := updatedHPA.Status.CurrentReplicas
+	if current < minReplicas(updatedHPA) || current > updatedHPA.Spec.MaxReplicas {
+		return fmt.Errorf("%w: %d not in [%d, %d]", ErrReplicasOutOfBounds, current, minReplicas(updatedHPA), updatedHPA.Spec.MaxReplicas)
+	}
+	logger.AppLog.LogInfo("horizontalpodautoscaler %s is active with %d replicas in namespace %s\n", hpa.Name, current, namespace)
+	return nil
+}
+
+func validateHPAsByNamespace(namespaces []string, hpasByNamespace map[string][]autoscalingv2.HorizontalPodAutoscaler, clientset kubernetes.Interface, interval, timeout time.Duration) error {
+	var err error
+	if interval <= 0 || timeout <= 0 {
+		return ErrInvalidInterval
+	}
+	for _, namespace := range namespaces {
+		for _, hpa := range hpasByNamespace[namespace] {
+			deadline := time.Now().Add(timeout)
+			for time.Now().Before(deadline) {
+				if err = checkHPAStatus(namespace, hpa, clientset); err == nil {
+					break
+				}
+				time.Sleep(interval)
+			}
+			if time.Now().After(deadline) {
+				return fmt.Errorf("timeout checking horizontalpodautoscaler status for %s in namespace %s, error: %v", hpa.Name, namespace, err)
+			}
+		}
+	}
+	return nil
+}
+
+func CheckHPAs(namespaces []string, clientset kubernetes.Interface, interval, timeout time.Duration) error {
+	logger.AppLog.LogInfo("Begin HorizontalPodAutoscaler validation")
+
+	hpasByNamespace, err := storeHPAsByNamespace(namespaces, clientset)
+	if err != nil {
+		if errors.Is(err, ErrNoHPA) {
+			logger.AppLog.LogWarning("No horizontalpodautoscalers found. Skipping validations.")
+			return nil
+		}
+		return err
+	}
+	if err = validateHPAsByNamespace(namespaces, hpasByNamespace, clientset, interval, timeout); err != nil {
+		return err
+	}
+
+	logger.AppLog.LogInfo("End HorizontalPodAutoscaler validation")
+	return nil
+}
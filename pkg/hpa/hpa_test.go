@@ -0,0 +1,136 @@
+package hpa
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vprashar2929/integration-test/pkg/logger"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var (
+	testNS         = "test-namespace"
+	testHPA        = "test-hpa"
+	testDep        = "test-deployment"
+	testDeployment = appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: testDep, Namespace: testNS}}
+)
+
+func newTestHPA(current int32, metricKnown bool, scalingActive corev1.ConditionStatus) *autoscalingv2.HorizontalPodAutoscaler {
+	minReplicas := int32(2)
+	utilization := int32(50)
+	metric := autoscalingv2.MetricStatus{
+		Type:     autoscalingv2.ResourceMetricSourceType,
+		Resource: &autoscalingv2.ResourceMetricStatus{Name: corev1.ResourceCPU},
+	}
+	if metricKnown {
+		metric.Resource.Current.AverageUtilization = &utilization
+	}
+	return &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: testHPA, Namespace: testNS},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: testDep},
+			MinReplicas:    &minReplicas,
+			MaxReplicas:    5,
+			Metrics: []autoscalingv2.MetricSpec{
+				{
+					Type: autoscalingv2.ResourceMetricSourceType,
+					Resource: &autoscalingv2.ResourceMetricSource{
+						Name:   corev1.ResourceCPU,
+						Target: autoscalingv2.MetricTarget{Type: autoscalingv2.UtilizationMetricType, AverageUtilization: &utilization},
+					},
+				},
+			},
+		},
+		Status: autoscalingv2.HorizontalPodAutoscalerStatus{
+			CurrentReplicas: current,
+			CurrentMetrics:  []autoscalingv2.MetricStatus{metric},
+			Conditions: []autoscalingv2.HorizontalPodAutoscalerCondition{
+				{Type: autoscalingv2.AbleToScale, Status: corev1.ConditionTrue},
+				{Type: autoscalingv2.ScalingActive, Status: scalingActive, Message: "the HPA was able to compute the replica count"},
+			},
+		},
+	}
+}
+
+func TestGetReplicaBounds(t *testing.T) {
+	clientset := fake.NewSimpleClientset(newTestHPA(2, true, corev1.ConditionTrue))
+	min, max, found, err := GetReplicaBounds(testNS, "Deployment", testDep, clientset)
+	if err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	if !found || min != 2 || max != 5 {
+		t.Errorf("expected bounds [2, 5], got: [%d, %d] found %v", min, max, found)
+	}
+	if _, _, found, _ = GetReplicaBounds(testNS, "StatefulSet", testDep, clientset); found {
+		t.Errorf("expected no autoscaler for statefulset")
+	}
+}
+
+func TestCheckHPAStatus(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	hpa := newTestHPA(3, true, corev1.ConditionTrue)
+	clientset := fake.NewSimpleClientset(hpa, &testDeployment)
+	if err := checkHPAStatus(testNS, *hpa, clientset); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+}
+
+func TestCheckHPAStatusScaleTargetMissing(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	hpa := newTestHPA(3, true, corev1.ConditionTrue)
+	clientset := fake.NewSimpleClientset(hpa)
+	if err := checkHPAStatus(testNS, *hpa, clientset); !errors.Is(err, ErrScaleTargetMissing) {
+		t.Fatalf("expected ErrScaleTargetMissing, got: %v", err)
+	}
+}
+
+func TestCheckHPAStatusNotActive(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	hpa := newTestHPA(3, true, corev1.ConditionFalse)
+	clientset := fake.NewSimpleClientset(hpa, &testDeployment)
+	if err := checkHPAStatus(testNS, *hpa, clientset); !errors.Is(err, ErrHPANotActive) {
+		t.Fatalf("expected ErrHPANotActive, got: %v", err)
+	}
+}
+
+func TestCheckHPAStatusMetricsUnknown(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	hpa := newTestHPA(3, false, corev1.ConditionTrue)
+	clientset := fake.NewSimpleClientset(hpa, &testDeployment)
+	if err := checkHPAStatus(testNS, *hpa, clientset); !errors.Is(err, ErrMetricsUnknown) {
+		t.Fatalf("expected ErrMetricsUnknown, got: %v", err)
+	}
+}
+
+func TestCheckHPAStatusOutOfBounds(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	hpa := newTestHPA(1, true, corev1.ConditionTrue)
+	clientset := fake.NewSimpleClientset(hpa, &testDeployment)
+	if err := checkHPAStatus(testNS, *hpa, clientset); !errors.Is(err, ErrReplicasOutOfBounds) {
+		t.Fatalf("expected ErrReplicasOutOfBounds, got: %v", err)
+	}
+}
+
+func TestCheckHPAs(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	clientset := fake.NewSimpleClientset(newTestHPA(3, true, corev1.ConditionTrue), &testDeployment)
+	interval := 1 * time.Second
+	timeout := 5 * time.Second
+	if err := CheckHPAs([]string{testNS}, clientset, interval, timeout); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+}
+
+func TestCheckHPAsNoNamespace(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	interval := 1 * time.Second
+	timeout := 5 * time.Second
+	if err := CheckHPAs([]string{}, fake.NewSimpleClientset(), interval, timeout); err != ErrNoNamespace {
+		t.Fatalf("expected ErrNoNamespace, got: %v", err)
+	}
+}
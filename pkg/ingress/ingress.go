@@ -0,0 +1,190 @@
+package ingress
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/vprashar2929/integration-test/pkg/logger"
+	"github.com/vprashar2929/integration-test/pkg/service"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+var (
+	ErrListingIngress        = errors.New("error listing ingresses in namespace")
+	ErrNoIngress             = errors.New("no ingress found in namespace")
+	ErrNoNamespace           = errors.New("no namespace provided")
+	ErrIngressNotHealthy     = errors.New("ingress not in healthy state")
+	ErrIngressAddressPending = errors.New("ingress load balancer address not assigned")
+	ErrTLSSecretMissing      = errors.New("ingress tls secret not found")
+	ErrBackendNotHealthy     = errors.New("ingress backend service not healthy")
+	ErrInvalidInterval       = errors.New("interval or timeout is invalid")
+)
+
+func getIngress(namespace string, clientset kubernetes.Interface) (*networkingv1.IngressList, error) {
+	ingress, err := clientset.NetworkingV1().Ingresses(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		logger.AppLog.LogError("error listing ingresses in namespace %s: %v\n", namespace, err)
+		return nil, ErrListingIngress
+	}
+	if len(ingress.Items) == 0 {
+		return nil, ErrNoIngress
+	}
+	return ingress, nil
+}
+
+func storeIngressesByNamespace(namespaces []string, clientset kubernetes.Interface) (map[string][]networkingv1.Ingress, error) {
+	if len(namespaces) == 0 {
+		return nil, ErrNoNamespace
+	}
+	ingressesByNamespace := make(map[string][]networkingv1.Ingress)
+	for _, namespace := range namespaces {
+		if namespace == "" {
+			logger.AppLog.LogError("Invalid namespace provided.")
+			continue
+		}
+		ingressList, err := getIngress(namespace, clientset)
+		if err != nil {
+			if errors.Is(err, ErrNoIngress) {
+				continue
+			}
+			return nil, err
+		}
+		ingressesByNamespace[namespace] = ingressList.Items
+	}
+	if len(ingressesByNamespace) == 0 {
+		return nil, ErrNoIngress
+	}
+	return ingressesByNamespace, nil
+}
+
+// getBackendServices returns the names of every service the ingress routes traffic to
+func getBackendServices(ingress *networkingv1.Ingress) []string {
+	seen := make(map[string]bool)
+	var services []string
+	add := func(backend *networkingv1.IngressBackend) {
+		if backend == nil || backend.Service == nil || seen[backend.Service.Name] {
+			return
+		}
+		seen[backend.Service.Name] = true
+		services = append(services, backend.Service.Name)
+	}
+	add(ingress.Spec.DefaultBackend)
+	for _, rule := range ingress.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for i := range rule.HTTP.Paths {
+			add(&rule.HTTP.Paths[i].Backend)
+		}
+	}
+	return services
+}
+
+// getIngressURLs returns a URL for every host/path pair of the ingress, using https for hosts covered by TLS
+func getIngressURLs(ingress *networkingv1.Ingress) []string {
+	tlsHosts := make(map[string]bool)
+	for _, tls := range ingress.Spec.TLS {
+		for _, host := range tls.Hosts {
+			tlsHosts[host] = true
+		}
+	}
+	var urls []string
+	for _, rule := range ingress.Spec.Rules {
+		if rule.Host == "" || rule.Host[0] == '*' {
+			continue
+		}
+		scheme := "http"
+		if tlsHosts[rule.Host] {
+			scheme = "https"
+		}
+		path := "/"
+		if rule.HTTP != nil && len(rule.HTTP.Paths) > 0 && rule.HTTP.Paths[0].Path != "" {
+			path = rule.HTTP.Paths[0].Path
+		}
+		urls = append(urls, scheme+"://"+rule.Host+path)
+	}
+	return urls
+}
+
+func checkIngressStatus(namespace string, ingress networkingv1.Ingress, clientset kubernetes.Interface, probe bool) error {
+	updatedIngress, err := clientset.NetworkingV1().Ingresses(namespace).Get(context.TODO(), ingress.Name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	for _, tls := range updatedIngress.Spec.TLS {
+		if tls.SecretName == "" {
+			continue
+		}
+		if _, err := clientset.CoreV1().Secrets(namespace).Get(context.TODO(), tls.SecretName, metav1.GetOptions{}); err != nil {
+			return fmt.Errorf("%w: %s", ErrTLSSecretMissing, tls.SecretName)
+		}
+	}
+	for _, backend := range getBackendServices(updatedIngress) {
+		if err := service.GetServiceStatus(namespace, backend, clientset); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrBackendNotHealthy, backend, err)
+		}
+	}
+	assigned := false
+	for _, lb := range updatedIngress.Status.LoadBalancer.Ingress {
+		if lb.IP != "" || lb.Hostname != "" {
+			assigned = true
+		}
+	}
+	if !assigned {
+		return ErrIngressAddressPending
+	}
+	if probe {
+		for _, url := range getIngressURLs(updatedIngress) {
+			if err := probeURL(url); err != nil {
+				return err
+			}
+		}
+	}
+	logger.AppLog.LogInfo("ingress %s is available in namespace %s\n", ingress.Name, namespace)
+	return nil
+}
+
+func validateIngressesByNamespace(namespaces []string, ingressesByNamespace map[string][]networkingv1.Ingress, clientset kubernetes.Interface, probe bool, interval, timeout time.Duration) error {
+	var err error
+	if interval <= 0 || timeout <= 0 {
+		return ErrInvalidInterval
+	}
+	for _, namespace := range namespaces {
+		for _, ingress := range ingressesByNamespace[namespace] {
+			deadline := time.Now().Add(timeout)
+			for time.Now().Before(deadline) {
+				if err = checkIngressStatus(namespace, ingress, clientset, probe); err == nil {
+					break
+				}
+				time.Sleep(interval)
+			}
+			if time.Now().After(deadline) {
+				return fmt.Errorf("timeout checking ingress status for %s in namespace %s, error: %v", ingress.Name, namespace, err)
+			}
+		}
+	}
+	return nil
+}
+
+func CheckIngresses(namespaces []string, clientset kubernetes.Interface, probe bool, interval, timeout time.Duration) error {
+	logger.AppLog.LogInfo("Begin Ingress validation")
+
+	ingressesByNamespace, err := storeIngressesByNamespace(namespaces, clientset)
+	if err != nil {
+		if errors.Is(err, ErrNoIngress) {
+			logger.AppLog.LogWarning("No ingresses found. Skipping validations.")
+			return nil
+		}
+		return err
+	}
+	if err = validateIngressesByNamespace(namespaces, ingressesByNamespace, clientset, probe, interval, timeout); err != nil {
+		return err
+	}
+
+	logger.AppLog.LogInfo("End Ingress validation")
+	return nil
+}
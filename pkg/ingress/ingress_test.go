@@ -0,0 +1,194 @@
+package ingress
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vprashar2929/integration-test/pkg/logger"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var (
+	testNS      = "test-namespace"
+	testIngress = "test-ingress"
+	testSvc     = "test-service"
+	testService = corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testSvc,
+			Namespace: testNS,
+		},
+		Spec: corev1.ServiceSpec{
+			Type: corev1.ServiceTypeClusterIP,
+			Ports: []corev1.ServicePort{
+				{Name: "http", Port: 8080},
+			},
+		},
+	}
+	testEndpoints = corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testSvc,
+			Namespace: testNS,
+		},
+		Subsets: []corev1.EndpointSubset{
+			{
+				Ports:     []corev1.EndpointPort{{Name: "http", Port: 8080}},
+				Addresses: []corev1.EndpointAddress{{IP: "10.0.0.1"}},
+			},
+		},
+	}
+	testTLSSecret = corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-tls",
+			Namespace: testNS,
+		},
+	}
+)
+
+func newTestIngress(host string, withAddress bool) *networkingv1.Ingress {
+	ingress := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testIngress,
+			Namespace: testNS,
+		},
+		Spec: networkingv1.IngressSpec{
+			TLS: []networkingv1.IngressTLS{
+				{Hosts: []string{"secure.example.com"}, SecretName: "test-tls"},
+			},
+			Rules: []networkingv1.IngressRule{
+				{
+					Host: host,
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{
+								{
+									Path: "/healthz",
+									Backend: networkingv1.IngressBackend{
+										Service: &networkingv1.IngressServiceBackend{
+											Name: testSvc,
+											Port: networkingv1.ServiceBackendPort{Name: "http"},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	if withAddress {
+		ingress.Status.LoadBalancer.Ingress = []networkingv1.IngressLoadBalancerIngress{{IP: "203.0.113.10"}}
+	}
+	return ingress
+}
+
+func TestGetIngressNoIngress(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	clientset := fake.NewSimpleClientset()
+	_, err := getIngress(testNS, clientset)
+	if err != ErrNoIngress {
+		t.Fatalf("expected ErrNoIngress, got: %v", err)
+	}
+}
+
+func TestGetIngressURLs(t *testing.T) {
+	ingress := newTestIngress("secure.example.com", true)
+	urls := getIngressURLs(ingress)
+	if len(urls) != 1 || urls[0] != "https://secure.example.com/healthz" {
+		t.Fatalf("expected https url, got: %v", urls)
+	}
+}
+
+func TestCheckIngressStatus(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	ingress := newTestIngress("app.example.com", true)
+	clientset := fake.NewSimpleClientset(ingress, &testService, &testEndpoints, &testTLSSecret)
+	if err := checkIngressStatus(testNS, *ingress, clientset, false); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+}
+
+func TestCheckIngressStatusAddressPending(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	ingress := newTestIngress("app.example.com", false)
+	clientset := fake.NewSimpleClientset(ingress, &testService, &testEndpoints, &testTLSSecret)
+	if err := checkIngressStatus(testNS, *ingress, clientset, false); err != ErrIngressAddressPending {
+		t.Fatalf("expected ErrIngressAddressPending, got: %v", err)
+	}
+}
+
+func TestCheckIngressStatusMissingSecret(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	ingress := newTestIngress("app.example.com", true)
+	clientset := fake.NewSimpleClientset(ingress, &testService, &testEndpoints)
+	if err := checkIngressStatus(testNS, *ingress, clientset, false); !errors.Is(err, ErrTLSSecretMissing) {
+		t.Fatalf("expected ErrTLSSecretMissing, got: %v", err)
+	}
+}
+
+func TestCheckIngressStatusBackendMissing(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	ingress := newTestIngress("app.example.com", true)
+	clientset := fake.NewSimpleClientset(ingress, &testTLSSecret)
+	if err := checkIngressStatus(testNS, *ingress, clientset, false); !errors.Is(err, ErrBackendNotHealthy) {
+		t.Fatalf("expected ErrBackendNotHealthy, got: %v", err)
+	}
+}
+
+func TestCheckIngressStatusProbe(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/healthz" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	ingress := newTestIngress(strings.TrimPrefix(server.URL, "http://"), true)
+	clientset := fake.NewSimpleClientset(ingress, &testService, &testEndpoints, &testTLSSecret)
+	if err := checkIngressStatus(testNS, *ingress, clientset, true); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+}
+
+func TestCheckIngressStatusProbeFailed(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	ingress := newTestIngress(strings.TrimPrefix(server.URL, "http://"), true)
+	clientset := fake.NewSimpleClientset(ingress, &testService, &testEndpoints, &testTLSSecret)
+	if err := checkIngressStatus(testNS, *ingress, clientset, true); !errors.Is(err, ErrProbeFailed) {
+		t.Fatalf("expected ErrProbeFailed, got: %v", err)
+	}
+}
+
+func TestCheckIngresses(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	ingress := newTestIngress("app.example.com", true)
+	clientset := fake.NewSimpleClientset(ingress, &testService, &testEndpoints, &testTLSSecret)
+	interval := 1 * time.Second
+	timeout := 5 * time.Second
+	if err := CheckIngresses([]string{testNS}, clientset, false, interval, timeout); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+}
+
+func TestCheckIngressesNoNamespace(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	clientset := fake.NewSimpleClientset()
+	interval := 1 * time.Second
+	timeout := 5 * time.Second
+	if err := CheckIngresses([]string{}, clientset, false, interval, timeout); err != ErrNoNamespace {
+		t.Fatalf("expected ErrNoNamespace, got: %v", err)
+	}
+}
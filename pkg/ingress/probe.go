@@ -0,0 +1,29 @@
+package ingress
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/vprashar2929/integration-test/pkg/logger"
+)
+
+var ErrProbeFailed = errors.New("http probe of exposed host failed")
+
+// httpClient is used to probe exposed hosts. Unit tests point it at a local test server.
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+func probeURL(url string) error {
+	start := time.Now()
+	resp, err := httpClient.Get(url)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrProbeFailed, url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("%w: %s returned %s", ErrProbeFailed, url, resp.Status)
+	}
+	logger.AppLog.LogInfo("probe of %s returned %s in %v\n", url, resp.Status, time.Since(start))
+	return nil
+}
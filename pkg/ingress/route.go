@@ -0,0 +1,193 @@
+package ingress
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/vprashar2929/integration-test/pkg/logger"
+	"github.com/vprashar2929/integration-test/pkg/service"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+// RouteGVR identifies OpenShift routes. Routes are read through the dynamic client so the tool
+// doesn't depend on the OpenShift API types and keeps working on plain Kubernetes.
+var RouteGVR = schema.GroupVersionResource{Group: "route.openshift.io", Version: "v1", Resource: "routes"}
+
+var (
+	ErrListingRoute     = errors.New("error listing routes in namespace")
+	ErrNoRoute          = errors.New("no route found in namespace")
+	ErrRouteNotAdmitted = errors.New("route not admitted by any router")
+	ErrRouteTarget      = errors.New("route target service not healthy")
+)
+
+func getRoute(namespace string, dynamicClient dynamic.Interface) (*unstructured.UnstructuredList, error) {
+	routes, err := dynamicClient.Resource(RouteGVR).Namespace(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			logger.AppLog.LogDebug("route API not served by cluster\n")
+			return nil, ErrNoRoute
+		}
+		logger.AppLog.LogError("error listing routes in namespace %s: %v\n", namespace, err)
+		return nil, ErrListingRoute
+	}
+	if len(routes.Items) == 0 {
+		return nil, ErrNoRoute
+	}
+	return routes, nil
+}
+
+func storeRoutesByNamespace(namespaces []string, dynamicClient dynamic.Interface) (map[string][]unstructured.Unstructured, error) {
+	if len(namespaces) == 0 {
+		return nil, ErrNoNamespace
+	}
+	routesByNamespace := make(map[string][]unstructured.Unstructured)
+	for _, namespace := range namespaces {
+		if namespace == "" {
+			logger.AppLog.LogError("Invalid namespace provided.")
+			continue
+		}
+		routeList, err := getRoute(namespace, dynamicClient)
+		if err != nil {
+			if errors.Is(err, ErrNoRoute) {
+				continue
+			}
+			return nil, err
+		}
+		routesByNamespace[namespace] = routeList.Items
+	}
+	if len(routesByNamespace) == 0 {
+		return nil, ErrNoRoute
+	}
+	return routesByNamespace, nil
+}
+
+// isRouteAdmitted reports whether at least one router admitted the route
+func isRouteAdmitted(route *unstructured.Unstructured) bool {
+	ingresses, _, _ := unstructured.NestedSlice(route.Object, "status", "ingress")
+	for _, ingress := range ingresses {
+		ingressMap, ok := ingress.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		conditions, _, _ := unstructured.NestedSlice(ingressMap, "conditions")
+		for _, condition := range conditions {
+			conditionMap, ok := condition.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if conditionMap["type"] == "Admitted" && conditionMap["status"] == "True" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// getRouteServices returns the services referenced by spec.to and spec.alternateBackends
+func getRouteServices(route *unstructured.Unstructured) []string {
+	var services []string
+	backends := []interface{}{}
+	if to, found, _ := unstructured.NestedMap(route.Object, "spec", "to"); found {
+		backends = append(backends, to)
+	}
+	alternates, _, _ := unstructured.NestedSlice(route.Object, "spec", "alternateBackends")
+	backends = append(backends, alternates...)
+	for _, backend := range backends {
+		backendMap, ok := backend.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		kind, _ := backendMap["kind"].(string)
+		name, _ := backendMap["name"].(string)
+		if (kind == "" || kind == "Service") && name != "" {
+			services = append(services, name)
+		}
+	}
+	return services
+}
+
+func getRouteURL(route *unstructured.Unstructured) string {
+	host, _, _ := unstructured.NestedString(route.Object, "spec", "host")
+	if host == "" {
+		return ""
+	}
+	path, _, _ := unstructured.NestedString(route.Object, "spec", "path")
+	if path == "" {
+		path = "/"
+	}
+	scheme := "http"
+	if _, found, _ := unstructured.NestedMap(route.Object, "spec", "tls"); found {
+		scheme = "https"
+	}
+	return scheme + "://" + host + path
+}
+
+func checkRouteStatus(namespace string, route unstructured.Unstructured, dynamicClient dynamic.Interface, clientset kubernetes.Interface, probe bool) error {
+	updatedRoute, err := dynamicClient.Resource(RouteGVR).Namespace(namespace).Get(context.TODO(), route.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if !isRouteAdmitted(updatedRoute) {
+		return ErrRouteNotAdmitted
+	}
+	for _, backend := range getRouteServices(updatedRoute) {
+		if err := service.GetServiceStatus(namespace, backend, clientset); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrRouteTarget, backend, err)
+		}
+	}
+	if url := getRouteURL(updatedRoute); probe && url != "" {
+		if err := probeURL(url); err != nil {
+			return err
+		}
+	}
+	logger.AppLog.LogInfo("route %s is available in namespace %s\n", route.GetName(), namespace)
+	return nil
+}
+
+func validateRoutesByNamespace(namespaces []string, routesByNamespace map[string][]unstructured.Unstructured, dynamicClient dynamic.Interface, clientset kubernetes.Interface, probe bool, interval, timeout time.Duration) error {
+	var err error
+	if interval <= 0 || timeout <= 0 {
+		return ErrInvalidInterval
+	}
+	for _, namespace := range namespaces {
+		for _, route := range routesByNamespace[namespace] {
+			deadline := time.Now().Add(timeout)
+			for time.Now().Before(deadline) {
+				if err = checkRouteStatus(namespace, route, dynamicClient, clientset, probe); err == nil {
+					break
+				}
+				time.Sleep(interval)
+			}
+			if time.Now().After(deadline) {
+				return fmt.Errorf("timeout checking route status for %s in namespace %s, error: %v", route.GetName(), namespace, err)
+			}
+		}
+	}
+	return nil
+}
+
+func CheckRoutes(namespaces []string, dynamicClient dynamic.Interface, clientset kubernetes.Interface, probe bool, interval, timeout time.Duration) error {
+	logger.AppLog.LogInfo("Begin Route validation")
+
+	routesByNamespace, err := storeRoutesByNamespace(namespaces, dynamicClient)
+	if err != nil {
+		if errors.Is(err, ErrNoRoute) {
+			logger.AppLog.LogWarning("No routes found. Skipping validations.")
+			return nil
+		}
+		return err
+	}
+	if err = validateRoutesByNamespace(namespaces, routesByNamespace, dynamicClient, clientset, probe, interval, timeout); err != nil {
+		return err
+	}
+
+	logger.AppLog.LogInfo("End Route validation")
+	return nil
+}
@@ -0,0 +1,90 @@
+package ingress
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vprashar2929/integration-test/pkg/logger"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newTestRoute(name, service string, admitted string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "route.openshift.io/v1",
+			"kind":       "Route",
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": testNS,
+			},
+			"spec": map[string]interface{}{
+				"host": "app.apps.example.com",
+				"to": map[string]interface{}{
+					"kind": "Service",
+					"name": service,
+				},
+			},
+			"status": map[string]interface{}{
+				"ingress": []interface{}{
+					map[string]interface{}{
+						"routerName": "default",
+						"conditions": []interface{}{
+							map[string]interface{}{"type": "Admitted", "status": admitted},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func newRouteClient(objects ...runtime.Object) *dynamicfake.FakeDynamicClient {
+	return dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{RouteGVR: "RouteList"}, objects...)
+}
+
+func TestCheckRouteStatus(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	route := newTestRoute("test-route", testSvc, "True")
+	dynamicClient := newRouteClient(route)
+	clientset := fake.NewSimpleClientset(&testService, &testEndpoints)
+	if err := checkRouteStatus(testNS, *route, dynamicClient, clientset, false); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+}
+
+func TestCheckRouteStatusNotAdmitted(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	route := newTestRoute("test-route", testSvc, "False")
+	dynamicClient := newRouteClient(route)
+	clientset := fake.NewSimpleClientset(&testService, &testEndpoints)
+	if err := checkRouteStatus(testNS, *route, dynamicClient, clientset, false); err != ErrRouteNotAdmitted {
+		t.Fatalf("expected ErrRouteNotAdmitted, got: %v", err)
+	}
+}
+
+func TestCheckRouteStatusMissingService(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	route := newTestRoute("test-route", "missing", "True")
+	dynamicClient := newRouteClient(route)
+	clientset := fake.NewSimpleClientset()
+	if err := checkRouteStatus(testNS, *route, dynamicClient, clientset, false); !errors.Is(err, ErrRouteTarget) {
+		t.Fatalf("expected ErrRouteTarget, got: %v", err)
+	}
+}
+
+func TestCheckRoutesNoRoutes(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	dynamicClient := newRouteClient()
+	clientset := fake.NewSimpleClientset()
+	interval := 1 * time.Second
+	timeout := 5 * time.Second
+	if err := CheckRoutes([]string{testNS}, dynamicClient, clientset, false, interval, timeout); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+package metrics
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const metricsNamespace = "integration_test"
+
+// Registry holds every metric of the tool, the default registry is left alone so only check results are exposed
+var Registry = prometheus.NewRegistry()
+
+var (
+	objectStatus = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "object_check_status",
+		Help:      "Result of the last check of an object, 1 when it passed and 0 when it failed.",
+	}, []string{"kind", "namespace", "name"})
+	objectFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "object_check_failures_total",
+		Help:      "Number of failed checks of an object.",
+	}, []string{"kind", "namespace", "name"})
+	checkerStatus = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "checker_status",
+		Help:      "Result of the last run of a checker, 1 when it passed and 0 when it failed.",
+	}, []string{"checker"})
+	checkerDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "checker_duration_seconds",
+		Help:      "Time a checker took to validate every object.",
+		Buckets:   prometheus.ExponentialBuckets(0.1, 2, 14),
+	}, []string{"checker"})
+	checkerFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "checker_failures_total",
+		Help:      "Number of failed runs of a checker.",
+	}, []string{"checker"})
+	runDuration = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "last_run_duration_seconds",
+		Help:      "Time the last run of all checkers took.",
+	})
+	runTimestamp = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "last_run_timestamp_seconds",
+		Help:      "Unix time the last run of all checkers finished.",
+	})
+	runs = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "runs_total",
+		Help:      "Number of runs of all checkers by result.",
+	}, []string{"result"})
+)
+
+// object identifies the series of a checked object
+type object struct {
+	kind, namespace, name string
+}
+
+var (
+	objectsMu sync.Mutex
+	// reported holds the objects exposed by object_check_status, seen the ones checked by the current run
+	reported = make(map[object]bool)
+	seen     = make(map[object]bool)
+)
+
+func init() {
+	Registry.MustRegister(objectStatus, objectFailures, checkerStatus, checkerDuration, checkerFailures, runDuration, runTimestamp, runs)
+}
+
+func status(err error) float64 {
+	if err != nil {
+		return 0
+	}
+	return 1
+}
+
+// BeginRun starts tracking the objects checked by a run, the previous results stay exposed until EndRun
+func BeginRun() {
+	objectsMu.Lock()
+	defer objectsMu.Unlock()
+	seen = make(map[object]bool)
+}
+
+// EndRun drops the object results the finished run did not report. Checkers stop at the first failing object,
+// so objects not checked again, or deleted since, would otherwise keep reporting a stale status.
+func EndRun() {
+	objectsMu.Lock()
+	defer objectsMu.Unlock()
+	for o := range reported {
+		if !seen[o] {
+			objectStatus.DeleteLabelValues(o.kind, o.namespace, o.name)
+		}
+	}
+	reported, seen = seen, make(map[object]bool)
+}
+
+// ObserveObject records the result of checking a single object such as a deployment
+func ObserveObject(kind, namespace, name string, err error) {
+	objectsMu.Lock()
+	reported[object{kind, namespace, name}] = true
+	seen[object{kind, namespace, name}] = true
+	objectsMu.Unlock()
+	objectStatus.WithLabelValues(kind, namespace, name).Set(status(err))
+	if err != nil {
+		objectFailures.WithLabelValues(kind, namespace, name).Inc()
+	}
+}
+
+// ObserveChecker records the result and duration of a checker
+func ObserveChecker(checker string, duration time.Duration, err error) {
+	checkerStatus.WithLabelValues(checker).Set(status(err))
+	checkerDuration.WithLabelValues(checker).Observe(duration.Seconds())
+	if err != nil {
+		checkerFailures.WithLabelValues(checker).Inc()
+	}
+}
+
+// ObserveRun records a run of all checkers
+func ObserveRun(duration time.Duration, failed bool) {
+	runDuration.Set(duration.Seconds())
+	runTimestamp.SetToCurrentTime()
+	result := "success"
+	if failed {
+		result = "failure"
+	}
+	runs.WithLabelValues(result).Inc()
+}
+
+// Handler serves the metrics in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandler(t *testing.T) {
+	ObserveObject("Deployment", "test-namespace", "ok", nil)
+	ObserveObject("Deployment", "test-namespace", "broken", errors.New("not ready"))
+	ObserveChecker("deployment", 2*time.Second, errors.New("not ready"))
+	ObserveRun(3*time.Second, true)
+
+	recorder := httptest.NewRecorder()
+	Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(recorder.Body)
+	for _, expected := range []string{
+		`integration_test_object_check_status{kind="Deployment",name="ok",namespace="test-namespace"} 1`,
+		`integration_test_object_check_status{kind="Deployment",name="broken",namespace="test-namespace"} 0`,
+		`integration_test_object_check_failures_total{kind="Deployment",name="broken",namespace="test-namespace"} 1`,
+		`integration_test_checker_status{checker="deployment"} 0`,
+		`integration_test_checker_duration_seconds_count{checker="deployment"} 1`,
+		`integration_test_runs_total{result="failure"} 1`,
+		`integration_test_last_run_duration_seconds 3`,
+	} {
+		if !strings.Contains(string(body), expected) {
+			t.Errorf("expected %s in metrics, got: %s", expected, body)
+		}
+	}
+}
+
+func scrape() string {
+	recorder := httptest.NewRecorder()
+	Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(recorder.Body)
+	return string(body)
+}
+
+func TestRunObjects(t *testing.T) {
+	BeginRun()
+	ObserveObject("StatefulSet", "test-namespace", "deleted", nil)
+	EndRun()
+	BeginRun()
+	ObserveObject("StatefulSet", "test-namespace", "current", nil)
+	// scrapes during a run still see the results of the previous one
+	if body := scrape(); !strings.Contains(body, `name="deleted"`) {
+		t.Fatalf("expected the previous results during the run, got: %s", body)
+	}
+	EndRun()
+	if body := scrape(); strings.Contains(body, `name="deleted"`) || !strings.Contains(body, `name="current"`) {
+		t.Fatalf("expected only objects of the last run, got: %s", body)
+	}
+}
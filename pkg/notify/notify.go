@@ -0,0 +1,159 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vprashar2929/integration-test/pkg/logger"
+)
+
+// maxSummaryErrors caps the errors listed in the summary, chat messages get truncated past a few kilobytes
+const maxSummaryErrors = 10
+
+// Event is why a notification is sent
+type Event string
+
+const (
+	EventFailure  Event = "failure"
+	EventRecovery Event = "recovery"
+	EventSuccess  Event = "success"
+)
+
+var (
+	ErrInvalidTrigger = errors.New("invalid notification trigger")
+	ErrNotifyFailed   = errors.New("notification failed")
+)
+
+// Notification carries the outcome of a run to the notifiers
+type Notification struct {
+	Event      Event     `json:"event"`
+	Passed     bool      `json:"passed"`
+	Namespaces []string  `json:"namespaces"`
+	Errors     []string  `json:"errors,omitempty"`
+	Time       time.Time `json:"time"`
+}
+
+// Summary is a short human readable description of the run
+func (n Notification) Summary() string {
+	namespaces := strings.Join(n.Namespaces, ", ")
+	switch n.Event {
+	case EventRecovery:
+		return fmt.Sprintf("integration-test recovered, all checks pass for namespaces %s", namespaces)
+	case EventSuccess:
+		return fmt.Sprintf("integration-test passed for namespaces %s", namespaces)
+	}
+	var summary strings.Builder
+	fmt.Fprintf(&summary, "integration-test failed for namespaces %s with %d errors:", namespaces, len(n.Errors))
+	for i, err := range n.Errors {
+		if i == maxSummaryErrors {
+			fmt.Fprintf(&summary, "\n- and %d more", len(n.Errors)-maxSummaryErrors)
+			break
+		}
+		fmt.Fprintf(&summary, "\n- %s", err)
+	}
+	return summary.String()
+}
+
+// Notifier delivers notifications to a single destination
+type Notifier interface {
+	Name() string
+	Notify(ctx context.Context, notification Notification) error
+}
+
+// Triggers selects the events that are sent
+type Triggers map[Event]bool
+
+// ParseTriggers parses a comma separated list of failure, recovery and always
+func ParseTriggers(value string) (Triggers, error) {
+	triggers := make(Triggers)
+	for _, trigger := range strings.Split(value, ",") {
+		switch strings.TrimSpace(trigger) {
+		case "failure":
+			triggers[EventFailure] = true
+		case "recovery":
+			triggers[EventRecovery] = true
+		case "always":
+			triggers[EventFailure], triggers[EventRecovery], triggers[EventSuccess] = true, true, true
+		default:
+			return nil, fmt.Errorf("%w: %q, supported triggers are failure, recovery, always", ErrInvalidTrigger, trigger)
+		}
+	}
+	return triggers, nil
+}
+
+// Dispatcher sends the outcome of runs to every notifier, it remembers the previous outcome of each set of
+// namespaces and suite to detect recoveries. The outcomes are kept in memory only.
+type Dispatcher struct {
+	notifiers  []Notifier
+	triggers   Triggers
+	timeout    time.Duration
+	mu         sync.Mutex
+	lastFailed map[string]bool
+}
+
+// NewDispatcher returns a dispatcher giving each notifier timeout to deliver
+func NewDispatcher(notifiers []Notifier, triggers Triggers, timeout time.Duration) *Dispatcher {
+	return &Dispatcher{notifiers: notifiers, triggers: triggers, timeout: timeout, lastFailed: make(map[string]bool)}
+}
+
+// runKey identifies runs of the same namespaces and suite regardless of their order
+func runKey(namespaces, suite []string) string {
+	sortedNamespaces := append([]string(nil), namespaces...)
+	sortedSuite := append([]string(nil), suite...)
+	sort.Strings(sortedNamespaces)
+	sort.Strings(sortedSuite)
+	return strings.Join(sortedNamespaces, ",") + "/" + strings.Join(sortedSuite, ",")
+}
+
+// event returns the event of a run outcome, a passing run after a failing one of the same key is a recovery
+func (d *Dispatcher) event(key string, failed bool) Event {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	recovered := d.lastFailed[key] && !failed
+	d.lastFailed[key] = failed
+	switch {
+	case failed:
+		return EventFailure
+	case recovered:
+		return EventRecovery
+	}
+	return EventSuccess
+}
+
+// Report notifies about a finished run of suite, every enabled checker when empty, when its event is enabled.
+// Every notifier is tried, failures are logged and returned together.
+func (d *Dispatcher) Report(namespaces, suite []string, errs []error) error {
+	notification := Notification{
+		Event:      d.event(runKey(namespaces, suite), len(errs) > 0),
+		Passed:     len(errs) == 0,
+		Namespaces: namespaces,
+		Time:       time.Now().UTC(),
+	}
+	for _, err := range errs {
+		notification.Errors = append(notification.Errors, err.Error())
+	}
+	if !d.triggers[notification.Event] {
+		return nil
+	}
+	var failed []string
+	for _, notifier := range d.notifiers {
+		ctx, cancel := context.WithTimeout(context.Background(), d.timeout)
+		err := notifier.Notify(ctx, notification)
+		cancel()
+		if err != nil {
+			logger.AppLog.LogError("cannot send %s notification through %s. reason: %v\n", notification.Event, notifier.Name(), err)
+			failed = append(failed, notifier.Name())
+			continue
+		}
+		logger.AppLog.LogInfo("sent %s notification through %s\n", notification.Event, notifier.Name())
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%w: %s", ErrNotifyFailed, strings.Join(failed, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vprashar2929/integration-test/pkg/logger"
+)
+
+type fakeNotifier struct {
+	err  error
+	sent []Notification
+}
+
+func (f *fakeNotifier) Name() string {
+	return "fake"
+}
+
+func (f *fakeNotifier) Notify(ctx context.Context, notification Notification) error {
+	f.sent = append(f.sent, notification)
+	return f.err
+}
+
+func TestParseTriggers(t *testing.T) {
+	triggers, err := ParseTriggers("failure, recovery")
+	if err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	if !triggers[EventFailure] || !triggers[EventRecovery] || triggers[EventSuccess] {
+		t.Fatalf("expected failure and recovery, got: %v", triggers)
+	}
+	triggers, err = ParseTriggers("always")
+	if err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	if !triggers[EventSuccess] {
+		t.Fatalf("expected success with always, got: %v", triggers)
+	}
+	if _, err = ParseTriggers("sometimes"); !errors.Is(err, ErrInvalidTrigger) {
+		t.Fatalf("expected %v, got: %v", ErrInvalidTrigger, err)
+	}
+}
+
+func TestReportFailureAndRecovery(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	notifier := &fakeNotifier{}
+	triggers, _ := ParseTriggers("failure,recovery")
+	d := NewDispatcher([]Notifier{notifier}, triggers, time.Second)
+
+	runs := [][]error{nil, {errors.New("deployment: timeout")}, {errors.New("deployment: timeout")}, nil, nil}
+	for _, errs := range runs {
+		if err := d.Report([]string{"default"}, nil, errs); err != nil {
+			t.Fatalf("expected nil, got: %v", err)
+		}
+	}
+	expected := []Event{EventFailure, EventFailure, EventRecovery}
+	if len(notifier.sent) != len(expected) {
+		t.Fatalf("expected %d notifications, got: %d", len(expected), len(notifier.sent))
+	}
+	for i, event := range expected {
+		if notifier.sent[i].Event != event {
+			t.Fatalf("expected %s for notification %d, got: %s", event, i, notifier.sent[i].Event)
+		}
+	}
+	if notifier.sent[0].Passed || notifier.sent[0].Errors[0] != "deployment: timeout" {
+		t.Fatalf("expected the failed run errors, got: %+v", notifier.sent[0])
+	}
+}
+
+func TestReportRecoveryPerRun(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	notifier := &fakeNotifier{}
+	triggers, _ := ParseTriggers("failure,recovery")
+	d := NewDispatcher([]Notifier{notifier}, triggers, time.Second)
+
+	failed := []error{errors.New("deployment: timeout")}
+	reports := []struct {
+		namespaces, suite []string
+		errs              []error
+	}{
+		{[]string{"a", "b"}, []string{"deployment"}, failed},
+		{[]string{"c"}, nil, nil},
+		{[]string{"a", "b"}, []string{"service"}, nil},
+		{[]string{"b", "a"}, []string{"deployment"}, nil},
+	}
+	for _, report := range reports {
+		if err := d.Report(report.namespaces, report.suite, report.errs); err != nil {
+			t.Fatalf("expected nil, got: %v", err)
+		}
+	}
+	// passing runs of other namespaces or suites neither recover nor hide the failure
+	expected := []Event{EventFailure, EventRecovery}
+	if len(notifier.sent) != len(expected) {
+		t.Fatalf("expected %d notifications, got: %+v", len(expected), notifier.sent)
+	}
+	for i, event := range expected {
+		if notifier.sent[i].Event != event {
+			t.Fatalf("expected %s for notification %d, got: %s", event, i, notifier.sent[i].Event)
+		}
+	}
+}
+
+func TestReportNotifierError(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	failing := &fakeNotifier{err: errors.New("connection refused")}
+	working := &fakeNotifier{}
+	triggers, _ := ParseTriggers("always")
+	d := NewDispatcher([]Notifier{failing, working}, triggers, time.Second)
+	if err := d.Report([]string{"default"}, nil, nil); !errors.Is(err, ErrNotifyFailed) {
+		t.Fatalf("expected %v, got: %v", ErrNotifyFailed, err)
+	}
+	if len(working.sent) != 1 {
+		t.Fatalf("expected the remaining notifiers to be tried, got: %d notifications", len(working.sent))
+	}
+}
+
+func TestSummaryTruncated(t *testing.T) {
+	notification := Notification{Event: EventFailure, Namespaces: []string{"default"}}
+	for i := 0; i < maxSummaryErrors+3; i++ {
+		notification.Errors = append(notification.Errors, "service: no endpoints")
+	}
+	summary := notification.Summary()
+	if strings.Count(summary, "service: no endpoints") != maxSummaryErrors || !strings.Contains(summary, "and 3 more") {
+		t.Fatalf("expected %d errors and a truncation note, got: %s", maxSummaryErrors, summary)
+	}
+}
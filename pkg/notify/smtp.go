@@ -0,0 +1,113 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// smtpNotifier mails the summary, the context deadline bounds the whole SMTP conversation
+type smtpNotifier struct {
+	addr string
+	from string
+	to   []string
+	auth smtp.Auth
+	// tlsConfig is used for STARTTLS, the server name is always set from addr
+	tlsConfig *tls.Config
+}
+
+// NewSMTPNotifier returns a notifier mailing through the server at addr. Authentication is skipped when
+// username is empty, net/smtp only sends credentials over TLS or to localhost.
+func NewSMTPNotifier(addr, from string, to []string, username, password string) Notifier {
+	notifier := &smtpNotifier{addr: addr, from: from, to: to}
+	if username != "" {
+		host, _, _ := net.SplitHostPort(addr)
+		notifier.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return notifier
+}
+
+func (s *smtpNotifier) Name() string {
+	return "smtp"
+}
+
+// headerValue drops line breaks so a value cannot end its header and inject new ones
+func headerValue(value string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(value)
+}
+
+func (s *smtpNotifier) message(notification Notification) []byte {
+	subject := "integration-test " + string(notification.Event)
+	if len(notification.Namespaces) > 0 {
+		subject += " in " + strings.Join(notification.Namespaces, ", ")
+	}
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", headerValue(s.from))
+	fmt.Fprintf(&msg, "To: %s\r\n", headerValue(strings.Join(s.to, ", ")))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", headerValue(subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", notification.Time.Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(notification.Summary(), "\n", "\r\n"))
+	msg.WriteString("\r\n")
+	return msg.Bytes()
+}
+
+func (s *smtpNotifier) Notify(ctx context.Context, notification Notification) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		if err = conn.SetDeadline(deadline); err != nil {
+			conn.Close()
+			return err
+		}
+	}
+	host, _, _ := net.SplitHostPort(s.addr)
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		config := &tls.Config{}
+		if s.tlsConfig != nil {
+			config = s.tlsConfig.Clone()
+		}
+		config.ServerName = host
+		if err = client.StartTLS(config); err != nil {
+			return err
+		}
+	}
+	if s.auth != nil {
+		if err = client.Auth(s.auth); err != nil {
+			return err
+		}
+	}
+	if err = client.Mail(s.from); err != nil {
+		return err
+	}
+	for _, to := range s.to {
+		if err = client.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = writer.Write(s.message(notification)); err != nil {
+		return err
+	}
+	if err = writer.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
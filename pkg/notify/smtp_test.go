@@ -0,0 +1,139 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// smtpServer is a minimal SMTP stand-in accepting a single mail, it sends the recipients and data on mails. With
+// a tlsConfig it offers STARTTLS and prefixes the mail with "TLS" once upgraded.
+func smtpServer(t *testing.T, tlsConfig *tls.Config) (string, chan string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	mails := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { conn.Close() }()
+		reader := bufio.NewReader(conn)
+		reply := func(line string) { fmt.Fprintf(conn, "%s\r\n", line) }
+		reply("220 localhost ESMTP")
+		var mail strings.Builder
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			command := strings.ToUpper(strings.TrimSpace(line))
+			switch {
+			case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+				if tlsConfig != nil {
+					reply("250-localhost")
+					reply("250 STARTTLS")
+				} else {
+					reply("250 localhost")
+				}
+			case command == "STARTTLS" && tlsConfig != nil:
+				reply("220 Ready to start TLS")
+				conn = tls.Server(conn, tlsConfig)
+				reader = bufio.NewReader(conn)
+				mail.WriteString("TLS\r\n")
+				tlsConfig = nil
+			case strings.HasPrefix(command, "RCPT"):
+				mail.WriteString(line)
+				reply("250 OK")
+			case strings.HasPrefix(command, "MAIL"):
+				reply("250 OK")
+			case command == "DATA":
+				reply("354 End data with <CR><LF>.<CR><LF>")
+				for {
+					data, err := reader.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if data == ".\r\n" {
+						break
+					}
+					mail.WriteString(data)
+				}
+				reply("250 OK")
+			case command == "QUIT":
+				reply("221 Bye")
+				mails <- mail.String()
+				return
+			default:
+				reply("502 Command not implemented")
+			}
+		}
+	}()
+	return listener.Addr().String(), mails
+}
+
+func TestSMTP(t *testing.T) {
+	addr, mails := smtpServer(t, nil)
+	notifier := NewSMTPNotifier(addr, "integration-test@example.com", []string{"oncall@example.com", "team@example.com"}, "", "")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := notifier.Notify(ctx, failedRun); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	mail := <-mails
+	for _, expected := range []string{"<oncall@example.com>", "<team@example.com>", "Subject: integration-test failure in default", failedRun.Errors[0]} {
+		if !strings.Contains(mail, expected) {
+			t.Fatalf("expected the mail to contain %q, got: %s", expected, mail)
+		}
+	}
+}
+
+func TestSMTPMessageHeaders(t *testing.T) {
+	notifier := NewSMTPNotifier("localhost:25", "integration-test@example.com", []string{"oncall@example.com"}, "", "").(*smtpNotifier)
+	notification := failedRun
+	notification.Namespaces = []string{"default\r\nBcc: attacker@example.com"}
+	headers, _, _ := strings.Cut(string(notifier.message(notification)), "\r\n\r\n")
+	if strings.Contains(headers, "\r\nBcc:") {
+		t.Fatalf("expected no injected header, got: %s", headers)
+	}
+}
+
+func TestSMTPStartTLS(t *testing.T) {
+	// the httptest certificate is valid for 127.0.0.1
+	srv := httptest.NewTLSServer(http.NotFoundHandler())
+	defer srv.Close()
+	addr, mails := smtpServer(t, srv.TLS)
+	notifier := NewSMTPNotifier(addr, "integration-test@example.com", []string{"oncall@example.com"}, "", "").(*smtpNotifier)
+	notifier.tlsConfig = &tls.Config{RootCAs: srv.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := notifier.Notify(ctx, failedRun); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	if mail := <-mails; !strings.HasPrefix(mail, "TLS") || !strings.Contains(mail, failedRun.Errors[0]) {
+		t.Fatalf("expected the mail over TLS, got: %s", mail)
+	}
+}
+
+func TestSMTPUnreachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+	notifier := NewSMTPNotifier(addr, "integration-test@example.com", []string{"oncall@example.com"}, "", "")
+	if err = notifier.Notify(context.TODO(), failedRun); err == nil {
+		t.Fatalf("expected an error, got: nil")
+	}
+}
@@ -0,0 +1,104 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"text/template"
+)
+
+// defaultWebhookTemplate sends the notification itself as JSON
+const defaultWebhookTemplate = `{{ json . }}`
+
+var templateFuncs = template.FuncMap{
+	// json encodes a value so strings from errors can be placed in a JSON body safely
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// webhookNotifier posts a body rendered from a template, the template gets the Notification along with its Summary
+type webhookNotifier struct {
+	url      string
+	template *template.Template
+	client   *http.Client
+}
+
+// NewWebhookNotifier returns a notifier posting to url, templatePath may be empty to post the notification as JSON
+func NewWebhookNotifier(url, templatePath string) (Notifier, error) {
+	text := defaultWebhookTemplate
+	if templatePath != "" {
+		data, err := os.ReadFile(templatePath)
+		if err != nil {
+			return nil, err
+		}
+		text = string(data)
+	}
+	tmpl, err := template.New("webhook").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	return &webhookNotifier{url: url, template: tmpl, client: http.DefaultClient}, nil
+}
+
+func (w *webhookNotifier) Name() string {
+	return "webhook"
+}
+
+func (w *webhookNotifier) Notify(ctx context.Context, notification Notification) error {
+	var body bytes.Buffer
+	if err := w.template.Execute(&body, notification); err != nil {
+		return err
+	}
+	return postJSON(ctx, w.client, w.url, body.Bytes())
+}
+
+func postJSON(ctx context.Context, client *http.Client, url string, body []byte) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		message, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+		return fmt.Errorf("webhook returned %d: %s", response.StatusCode, bytes.TrimSpace(message))
+	}
+	return nil
+}
+
+// slackNotifier posts the summary to a Slack compatible incoming webhook
+type slackNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// NewSlackNotifier returns a notifier posting to a Slack compatible incoming webhook url
+func NewSlackNotifier(url string) Notifier {
+	return &slackNotifier{url: url, client: http.DefaultClient}
+}
+
+func (s *slackNotifier) Name() string {
+	return "slack"
+}
+
+func (s *slackNotifier) Notify(ctx context.Context, notification Notification) error {
+	icon := ":x:"
+	if notification.Passed {
+		icon = ":white_check_mark:"
+	}
+	body, err := json.Marshal(map[string]string{"text": icon + " " + notification.Summary()})
+	if err != nil {
+		return err
+	}
+	return postJSON(ctx, s.client, s.url, body)
+}
@@ -0,0 +1,108 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// receiver records the bodies posted to it
+func receiver(t *testing.T, status int) (*httptest.Server, chan []byte) {
+	bodies := make(chan []byte, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("expected application/json, got: %s", r.Header.Get("Content-Type"))
+		}
+		body, _ := io.ReadAll(r.Body)
+		bodies <- body
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, bodies
+}
+
+var failedRun = Notification{
+	Event:      EventFailure,
+	Namespaces: []string{"default"},
+	Errors:     []string{`deployment: "app" not ready`},
+	Time:       time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC),
+}
+
+func TestWebhookDefaultBody(t *testing.T) {
+	srv, bodies := receiver(t, http.StatusOK)
+	notifier, err := NewWebhookNotifier(srv.URL, "")
+	if err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	if err = notifier.Notify(context.TODO(), failedRun); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	var received Notification
+	if err = json.Unmarshal(<-bodies, &received); err != nil {
+		t.Fatalf("expected a JSON body, got: %v", err)
+	}
+	if received.Event != EventFailure || received.Errors[0] != failedRun.Errors[0] {
+		t.Fatalf("expected the notification, got: %+v", received)
+	}
+}
+
+func TestWebhookTemplate(t *testing.T) {
+	srv, bodies := receiver(t, http.StatusAccepted)
+	path := filepath.Join(t.TempDir(), "body.tmpl")
+	if err := os.WriteFile(path, []byte(`{"title": "{{ .Event }}", "text": {{ json .Summary }}}`), 0o600); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	notifier, err := NewWebhookNotifier(srv.URL, path)
+	if err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	if err = notifier.Notify(context.TODO(), failedRun); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	var received map[string]string
+	if err = json.Unmarshal(<-bodies, &received); err != nil {
+		t.Fatalf("expected a JSON body, got: %v", err)
+	}
+	if received["title"] != "failure" || !strings.Contains(received["text"], `"app" not ready`) {
+		t.Fatalf("expected the rendered template, got: %v", received)
+	}
+}
+
+func TestWebhookInvalidTemplate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "body.tmpl")
+	if err := os.WriteFile(path, []byte(`{{ .Event `), 0o600); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	if _, err := NewWebhookNotifier("http://localhost", path); err == nil {
+		t.Fatalf("expected an error, got: nil")
+	}
+}
+
+func TestWebhookErrorStatus(t *testing.T) {
+	srv, _ := receiver(t, http.StatusInternalServerError)
+	notifier, _ := NewWebhookNotifier(srv.URL, "")
+	if err := notifier.Notify(context.TODO(), failedRun); err == nil || !strings.Contains(err.Error(), "500") {
+		t.Fatalf("expected the status in the error, got: %v", err)
+	}
+}
+
+func TestSlack(t *testing.T) {
+	srv, bodies := receiver(t, http.StatusOK)
+	if err := NewSlackNotifier(srv.URL).Notify(context.TODO(), failedRun); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	var received map[string]string
+	if err := json.Unmarshal(<-bodies, &received); err != nil {
+		t.Fatalf("expected a JSON body, got: %v", err)
+	}
+	if !strings.HasPrefix(received["text"], ":x: integration-test failed") || !strings.Contains(received["text"], failedRun.Errors[0]) {
+		t.Fatalf("expected the summary, got: %s", received["text"])
+	}
+}
@@ -0,0 +1,201 @@
+package olm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/vprashar2929/integration-test/pkg/deployment"
+	"github.com/vprashar2929/integration-test/pkg/logger"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	subscriptionAtLatest = "AtLatestKnown"
+	csvSucceeded         = "Succeeded"
+	installPlanComplete  = "Complete"
+)
+
+var (
+	SubscriptionGVR = schema.GroupVersionResource{Group: "operators.coreos.com", Version: "v1alpha1", Resource: "subscriptions"}
+	CSVGVR          = schema.GroupVersionResource{Group: "operators.coreos.com", Version: "v1alpha1", Resource: "clusterserviceversions"}
+	InstallPlanGVR  = schema.GroupVersionResource{Group: "operators.coreos.com", Version: "v1alpha1", Resource: "installplans"}
+)
+
+var (
+	ErrListingSubscription     = errors.New("error listing subscriptions in namespace")
+	ErrNoSubscription          = errors.New("no subscriptions found in namespace")
+	ErrNoNamespace             = errors.New("no namespace provided")
+	ErrSubscriptionNotAtLatest = errors.New("subscription not at latest known version")
+	ErrInstallPlanNotComplete  = errors.New("installplan not complete")
+	ErrCSVNotSucceeded         = errors.New("clusterserviceversion not succeeded")
+	ErrInvalidInterval         = errors.New("interval or timeout is invalid")
+)
+
+func getSubscription(namespace string, dynamicClient dynamic.Interface) (*unstructured.UnstructuredList, error) {
+	subscriptions, err := dynamicClient.Resource(SubscriptionGVR).Namespace(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		// OLM is not installed on the cluster
+		if apierrors.IsNotFound(err) {
+			return nil, ErrNoSubscription
+		}
+		logger.AppLog.LogError("error listing subscriptions in namespace %s: %v\n", namespace, err)
+		return nil, ErrListingSubscription
+	}
+	if len(subscriptions.Items) == 0 {
+		return nil, ErrNoSubscription
+	}
+	return subscriptions, nil
+}
+
+func storeSubscriptionsByNamespace(namespaces []string, dynamicClient dynamic.Interface) (map[string][]unstructured.Unstructured, error) {
+	if len(namespaces) == 0 {
+		return nil, ErrNoNamespace
+	}
+	subscriptionsByNamespace := make(map[string][]unstructured.Unstructured)
+	for _, namespace := range namespaces {
+		if namespace == "" {
+			logger.AppLog.LogError("Invalid namespace provided.")
+			continue
+		}
+		subscriptionList, err := getSubscription(namespace, dynamicClient)
+		if err != nil {
+			if errors.Is(err, ErrNoSubscription) {
+				continue
+			}
+			return nil, err
+		}
+		subscriptionsByNamespace[namespace] = subscriptionList.Items
+	}
+	if len(subscriptionsByNamespace) == 0 {
+		return nil, ErrNoSubscription
+	}
+	return subscriptionsByNamespace, nil
+}
+
+func checkInstallPlanStatus(namespace, name string, dynamicClient dynamic.Interface) error {
+	installPlan, err := dynamicClient.Resource(InstallPlanGVR).Namespace(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	phase, _, _ := unstructured.NestedString(installPlan.Object, "status", "phase")
+	if phase != installPlanComplete {
+		return fmt.Errorf("%w: %s is %q", ErrInstallPlanNotComplete, name, phase)
+	}
+	return nil
+}
+
+// checkSubscriptionStatus returns the name of the installed CSV once the subscription and its installplan are done
+func checkSubscriptionStatus(namespace string, subscription unstructured.Unstructured, dynamicClient dynamic.Interface) (string, error) {
+	updatedSubscription, err := dynamicClient.Resource(SubscriptionGVR).Namespace(namespace).Get(context.TODO(), subscription.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	state, _, _ := unstructured.NestedString(updatedSubscription.Object, "status", "state")
+	installedCSV, _, _ := unstructured.NestedString(updatedSubscription.Object, "status", "installedCSV")
+	if state != subscriptionAtLatest || installedCSV == "" {
+		return "", fmt.Errorf("%w: state is %q", ErrSubscriptionNotAtLatest, state)
+	}
+	installPlan, _, _ := unstructured.NestedString(updatedSubscription.Object, "status", "installPlanRef", "name")
+	if installPlan != "" {
+		if err := checkInstallPlanStatus(namespace, installPlan, dynamicClient); err != nil {
+			return "", err
+		}
+	}
+	logger.AppLog.LogInfo("subscription %s installed %s in namespace %s\n", subscription.GetName(), installedCSV, namespace)
+	return installedCSV, nil
+}
+
+// checkCSVStatus returns the deployments owned by the CSV once it has succeeded
+func checkCSVStatus(namespace, name string, dynamicClient dynamic.Interface) ([]string, error) {
+	csv, err := dynamicClient.Resource(CSVGVR).Namespace(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	phase, _, _ := unstructured.NestedString(csv.Object, "status", "phase")
+	if phase != csvSucceeded {
+		reason, _, _ := unstructured.NestedString(csv.Object, "status", "reason")
+		return nil, fmt.Errorf("%w: %s is %q %s", ErrCSVNotSucceeded, name, phase, reason)
+	}
+	var deployments []string
+	specs, _, _ := unstructured.NestedSlice(csv.Object, "spec", "install", "spec", "deployments")
+	for _, spec := range specs {
+		specMap, ok := spec.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if name, ok := specMap["name"].(string); ok && name != "" {
+			deployments = append(deployments, name)
+		}
+	}
+	logger.AppLog.LogInfo("clusterserviceversion %s succeeded in namespace %s\n", name, namespace)
+	return deployments, nil
+}
+
+func validateSubscriptionsByNamespace(namespaces []string, subscriptionsByNamespace map[string][]unstructured.Unstructured, dynamicClient dynamic.Interface, clientset kubernetes.Interface, interval, timeout time.Duration) error {
+	var err error
+	if interval <= 0 || timeout <= 0 {
+		return ErrInvalidInterval
+	}
+	for _, namespace := range namespaces {
+		for _, subscription := range subscriptionsByNamespace[namespace] {
+
+			// check subscription and installplan status
+			var installedCSV string
+			deadline := time.Now().Add(timeout)
+			for time.Now().Before(deadline) {
+				if installedCSV, err = checkSubscriptionStatus(namespace, subscription, dynamicClient); err == nil {
+					break
+				}
+				time.Sleep(interval)
+			}
+			if time.Now().After(deadline) {
+				return fmt.Errorf("timeout checking subscription status for %s in namespace %s, error: %v", subscription.GetName(), namespace, err)
+			}
+
+			// check csv status
+			var deployments []string
+			deadline = time.Now().Add(timeout)
+			for time.Now().Before(deadline) {
+				if deployments, err = checkCSVStatus(namespace, installedCSV, dynamicClient); err == nil {
+					break
+				}
+				time.Sleep(interval)
+			}
+			if time.Now().After(deadline) {
+				return fmt.Errorf("timeout checking clusterserviceversion status for %s in namespace %s, error: %v", installedCSV, namespace, err)
+			}
+
+			// check deployments installed by the csv
+			if err = deployment.CheckNamedDeployments(namespace, deployments, clientset, interval, timeout); err != nil {
+				return fmt.Errorf("deployments of clusterserviceversion %s in namespace %s not healthy, error: %w", installedCSV, namespace, err)
+			}
+		}
+	}
+	return nil
+}
+
+func CheckOperators(namespaces []string, dynamicClient dynamic.Interface, clientset kubernetes.Interface, interval, timeout time.Duration) error {
+	logger.AppLog.LogInfo("Begin OLM validation")
+
+	subscriptionsByNamespace, err := storeSubscriptionsByNamespace(namespaces, dynamicClient)
+	if err != nil {
+		if errors.Is(err, ErrNoSubscription) {
+			logger.AppLog.LogWarning("No subscriptions found. Skipping validations.")
+			return nil
+		}
+		return err
+	}
+	if err = validateSubscriptionsByNamespace(namespaces, subscriptionsByNamespace, dynamicClient, clientset, interval, timeout); err != nil {
+		return err
+	}
+
+	logger.AppLog.LogInfo("End OLM validation")
+	return nil
+}
@@ -0,0 +1,202 @@
+package olm
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vprashar2929/integration-test/pkg/logger"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var (
+	testNS          = "test-namespace"
+	testSub         = "test-operator"
+	testCSV         = "test-operator.v1.0.0"
+	testInstallPlan = "install-abcde"
+	testDep         = "test-operator-controller"
+	testLabels      = map[string]string{"app": "test-operator"}
+)
+
+func newTestObject(apiVersion, kind, name string, status, spec map[string]interface{}) *unstructured.Unstructured {
+	object := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": apiVersion,
+			"kind":       kind,
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": testNS,
+			},
+			"status": status,
+		},
+	}
+	if spec != nil {
+		object.Object["spec"] = spec
+	}
+	return object
+}
+
+func newTestSubscription(state string) *unstructured.Unstructured {
+	return newTestObject("operators.coreos.com/v1alpha1", "Subscription", testSub, map[string]interface{}{
+		"state":          state,
+		"installedCSV":   testCSV,
+		"installPlanRef": map[string]interface{}{"name": testInstallPlan},
+	}, nil)
+}
+
+func newTestCSV(phase string) *unstructured.Unstructured {
+	return newTestObject("operators.coreos.com/v1alpha1", "ClusterServiceVersion", testCSV, map[string]interface{}{
+		"phase": phase,
+	}, map[string]interface{}{
+		"install": map[string]interface{}{
+			"strategy": "deployment",
+			"spec": map[string]interface{}{
+				"deployments": []interface{}{
+					map[string]interface{}{"name": testDep},
+				},
+			},
+		},
+	})
+}
+
+func newTestInstallPlan(phase string) *unstructured.Unstructured {
+	return newTestObject("operators.coreos.com/v1alpha1", "InstallPlan", testInstallPlan, map[string]interface{}{
+		"phase": phase,
+	}, nil)
+}
+
+func newTestDynamicClient(objects ...runtime.Object) *dynamicfake.FakeDynamicClient {
+	return dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		SubscriptionGVR: "SubscriptionList",
+		CSVGVR:          "ClusterServiceVersionList",
+		InstallPlanGVR:  "InstallPlanList",
+	}, objects...)
+}
+
+func newTestClientset() *fake.Clientset {
+	replicas := int32(1)
+	return fake.NewSimpleClientset(
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        testDep,
+				Namespace:   testNS,
+				Generation:  1,
+				Annotations: map[string]string{"deployment.kubernetes.io/revision": "1"},
+			},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: &replicas,
+				Selector: &metav1.LabelSelector{MatchLabels: testLabels},
+			},
+			Status: appsv1.DeploymentStatus{
+				ObservedGeneration: 1,
+				Replicas:           1,
+				UpdatedReplicas:    1,
+				ReadyReplicas:      1,
+				AvailableReplicas:  1,
+			},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-operator-controller-abcde",
+				Namespace: testNS,
+				Labels:    testLabels,
+			},
+			Status: corev1.PodStatus{
+				Phase: corev1.PodRunning,
+			},
+		},
+	)
+}
+
+func TestStoreSubscriptionsByNamespaceNoSubscriptions(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	_, err := storeSubscriptionsByNamespace([]string{testNS}, newTestDynamicClient())
+	if err != ErrNoSubscription {
+		t.Fatalf("expected ErrNoSubscription, got: %v", err)
+	}
+}
+
+func TestCheckSubscriptionStatus(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	subscription := newTestSubscription(subscriptionAtLatest)
+	dynamicClient := newTestDynamicClient(subscription, newTestInstallPlan(installPlanComplete))
+	installedCSV, err := checkSubscriptionStatus(testNS, *subscription, dynamicClient)
+	if err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	if installedCSV != testCSV {
+		t.Errorf("expected %s, got: %s", testCSV, installedCSV)
+	}
+}
+
+func TestCheckSubscriptionStatusUpgradePending(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	subscription := newTestSubscription("UpgradePending")
+	dynamicClient := newTestDynamicClient(subscription, newTestInstallPlan(installPlanComplete))
+	if _, err := checkSubscriptionStatus(testNS, *subscription, dynamicClient); !errors.Is(err, ErrSubscriptionNotAtLatest) {
+		t.Fatalf("expected ErrSubscriptionNotAtLatest, got: %v", err)
+	}
+}
+
+func TestCheckSubscriptionStatusInstallPlanNotComplete(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	subscription := newTestSubscription(subscriptionAtLatest)
+	dynamicClient := newTestDynamicClient(subscription, newTestInstallPlan("Installing"))
+	if _, err := checkSubscriptionStatus(testNS, *subscription, dynamicClient); !errors.Is(err, ErrInstallPlanNotComplete) {
+		t.Fatalf("expected ErrInstallPlanNotComplete, got: %v", err)
+	}
+}
+
+func TestCheckCSVStatus(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	deployments, err := checkCSVStatus(testNS, testCSV, newTestDynamicClient(newTestCSV(csvSucceeded)))
+	if err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	if len(deployments) != 1 || deployments[0] != testDep {
+		t.Errorf("expected [%s], got: %v", testDep, deployments)
+	}
+}
+
+func TestCheckCSVStatusFailed(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	if _, err := checkCSVStatus(testNS, testCSV, newTestDynamicClient(newTestCSV("Failed"))); !errors.Is(err, ErrCSVNotSucceeded) {
+		t.Fatalf("expected ErrCSVNotSucceeded, got: %v", err)
+	}
+}
+
+func TestCheckOperators(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	dynamicClient := newTestDynamicClient(newTestSubscription(subscriptionAtLatest), newTestInstallPlan(installPlanComplete), newTestCSV(csvSucceeded))
+	interval := 1 * time.Second
+	timeout := 5 * time.Second
+	if err := CheckOperators([]string{testNS}, dynamicClient, newTestClientset(), interval, timeout); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+}
+
+func TestCheckOperatorsCSVFailed(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	dynamicClient := newTestDynamicClient(newTestSubscription(subscriptionAtLatest), newTestInstallPlan(installPlanComplete), newTestCSV("Failed"))
+	interval := 1 * time.Second
+	timeout := 2 * time.Second
+	if err := CheckOperators([]string{testNS}, dynamicClient, newTestClientset(), interval, timeout); err == nil {
+		t.Fatalf("expected error, got: %v", err)
+	}
+}
+
+func TestCheckOperatorsNoNamespace(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	interval := 1 * time.Second
+	timeout := 5 * time.Second
+	if err := CheckOperators([]string{}, newTestDynamicClient(), newTestClientset(), interval, timeout); err != ErrNoNamespace {
+		t.Fatalf("expected ErrNoNamespace, got: %v", err)
+	}
+}
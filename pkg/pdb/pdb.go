@@ -0,0 +1,128 @@
+package pdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/vprashar2929/integration-test/pkg/logger"
+	"github.com/vprashar2929/integration-test/pkg/workload"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+var (
+	ErrListingWorkload     = workload.ErrListingWorkload
+	ErrNoWorkload          = workload.ErrNoWorkload
+	ErrListingPDB          = errors.New("error listing poddisruptionbudgets in namespace")
+	ErrNoNamespace         = workload.ErrNoNamespace
+	ErrPDBSelectsNoPods    = errors.New("poddisruptionbudget selector matches no pods")
+	ErrPDBBlocksDisruption = errors.New("poddisruptionbudget allows no disruptions")
+	ErrPDBNoDisruptions    = errors.New("poddisruptionbudget is configured to allow no disruptions")
+	ErrInvalidInterval     = errors.New("interval or timeout is invalid")
+)
+
+// getCoveringPDBs returns the PDBs whose selector matches the pod template labels of the workload
+func getCoveringPDBs(namespace string, w workload.Workload, clientset kubernetes.Interface) ([]policyv1.PodDisruptionBudget, error) {
+	pdbList, err := clientset.PolicyV1().PodDisruptionBudgets(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		logger.AppLog.LogError("error listing poddisruptionbudgets in namespace %s: %v\n", namespace, err)
+		return nil, ErrListingPDB
+	}
+	var covering []policyv1.PodDisruptionBudget
+	for _, pdb := range pdbList.Items {
+		selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+		if err != nil {
+			logger.AppLog.LogWarning("poddisruptionbudget %s has an invalid selector: %v\n", pdb.Name, err)
+			continue
+		}
+		if selector.Matches(w.Labels) {
+			covering = append(covering, pdb)
+		}
+	}
+	return covering, nil
+}
+
+func checkPDBStatus(namespace string, pdb policyv1.PodDisruptionBudget, clientset kubernetes.Interface) error {
+	selector, _ := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+	pods, err := clientset.CoreV1().Pods(namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return err
+	}
+	if len(pods.Items) == 0 {
+		return fmt.Errorf("%w: %s", ErrPDBSelectsNoPods, pdb.Name)
+	}
+	// maxUnavailable 0 or minAvailable covering every pod never lets a pod go, waiting does not help
+	if pdb.Status.ExpectedPods > 0 && pdb.Status.DesiredHealthy >= pdb.Status.ExpectedPods {
+		return fmt.Errorf("%w: %s needs %d of %d pods healthy", ErrPDBNoDisruptions, pdb.Name, pdb.Status.DesiredHealthy, pdb.Status.ExpectedPods)
+	}
+	if pdb.Status.CurrentHealthy-pdb.Status.DesiredHealthy < 1 || pdb.Status.DisruptionsAllowed < 1 {
+		return fmt.Errorf("%w: %s has %d healthy pods and needs %d", ErrPDBBlocksDisruption, pdb.Name, pdb.Status.CurrentHealthy, pdb.Status.DesiredHealthy)
+	}
+	return nil
+}
+
+func checkWorkloadPDBs(namespace string, w workload.Workload, clientset kubernetes.Interface) error {
+	covering, err := getCoveringPDBs(namespace, w, clientset)
+	if err != nil {
+		return err
+	}
+	if len(covering) == 0 {
+		logger.AppLog.LogWarning("%s %s in namespace %s is not covered by a poddisruptionbudget\n", w.Kind, w.Name, namespace)
+		return nil
+	}
+	for _, pdb := range covering {
+		if err := checkPDBStatus(namespace, pdb, clientset); err != nil {
+			return err
+		}
+		logger.AppLog.LogInfo("%s %s is covered by poddisruptionbudget %s allowing %d disruptions in namespace %s\n", w.Kind, w.Name, pdb.Name, pdb.Status.DisruptionsAllowed, namespace)
+	}
+	return nil
+}
+
+func validatePDBsByNamespace(namespaces []string, workloadsByNamespace map[string][]workload.Workload, clientset kubernetes.Interface, interval, timeout time.Duration) error {
+	var err error
+	if interval <= 0 || timeout <= 0 {
+		return ErrInvalidInterval
+	}
+	for _, namespace := range namespaces {
+		for _, w := range workloadsByNamespace[namespace] {
+			deadline := time.Now().Add(timeout)
+			for time.Now().Before(deadline) {
+				if err = checkWorkloadPDBs(namespace, w, clientset); err == nil || errors.Is(err, ErrPDBNoDisruptions) {
+					break
+				}
+				time.Sleep(interval)
+			}
+			if errors.Is(err, ErrPDBNoDisruptions) {
+				return fmt.Errorf("%s %s in namespace %s: %w", w.Kind, w.Name, namespace, err)
+			}
+			if time.Now().After(deadline) {
+				return fmt.Errorf("timeout checking poddisruptionbudgets for %s %s in namespace %s, error: %v", w.Kind, w.Name, namespace, err)
+			}
+		}
+	}
+	return nil
+}
+
+func CheckPDBs(namespaces []string, clientset kubernetes.Interface, interval, timeout time.Duration) error {
+	logger.AppLog.LogInfo("Begin PodDisruptionBudget validation")
+
+	// only deployments and statefulsets are expected to be covered by a PDB
+	workloadsByNamespace, err := workload.ListByNamespace(namespaces, clientset, workload.KindDeployment, workload.KindStatefulSet)
+	if err != nil {
+		if errors.Is(err, ErrNoWorkload) {
+			logger.AppLog.LogWarning("No deployments or statefulsets found. Skipping validations.")
+			return nil
+		}
+		return err
+	}
+	if err = validatePDBsByNamespace(namespaces, workloadsByNamespace, clientset, interval, timeout); err != nil {
+		return err
+	}
+
+	logger.AppLog.LogInfo("End PodDisruptionBudget validation")
+	return nil
+}
@@ -0,0 +1,131 @@
+package pdb
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vprashar2929/integration-test/pkg/logger"
+	"github.com/vprashar2929/integration-test/pkg/workload"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var (
+	testNS         = "test-namespace"
+	testDep        = "test-deployment"
+	testPDB        = "test-pdb"
+	testLabels     = map[string]string{"app": "test-app"}
+	testDeployment = appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: testDep, Namespace: testNS},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: testLabels},
+			Template: corev1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: testLabels}},
+		},
+	}
+	testPod = corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: testNS, Labels: testLabels},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+)
+
+func newTestPDB(selector map[string]string, currentHealthy, desiredHealthy int32) *policyv1.PodDisruptionBudget {
+	return &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{Name: testPDB, Namespace: testNS},
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: selector},
+		},
+		Status: policyv1.PodDisruptionBudgetStatus{
+			CurrentHealthy:     currentHealthy,
+			DesiredHealthy:     desiredHealthy,
+			DisruptionsAllowed: currentHealthy - desiredHealthy,
+		},
+	}
+}
+
+func TestGetCoveringPDBs(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	clientset := fake.NewSimpleClientset(newTestPDB(testLabels, 2, 1))
+	workloads, _ := workload.List(testNS, fake.NewSimpleClientset(&testDeployment), workload.KindDeployment)
+	covering, err := getCoveringPDBs(testNS, workloads[0], clientset)
+	if err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	if len(covering) != 1 {
+		t.Fatalf("expected 1 covering pdb, got: %d", len(covering))
+	}
+	clientset = fake.NewSimpleClientset(newTestPDB(map[string]string{"app": "other"}, 2, 1))
+	if covering, _ = getCoveringPDBs(testNS, workloads[0], clientset); len(covering) != 0 {
+		t.Fatalf("expected no covering pdb, got: %d", len(covering))
+	}
+}
+
+func TestCheckPDBStatus(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	clientset := fake.NewSimpleClientset(&testPod)
+	if err := checkPDBStatus(testNS, *newTestPDB(testLabels, 2, 1), clientset); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	if err := checkPDBStatus(testNS, *newTestPDB(testLabels, 1, 1), clientset); !errors.Is(err, ErrPDBBlocksDisruption) {
+		t.Fatalf("expected ErrPDBBlocksDisruption, got: %v", err)
+	}
+	if err := checkPDBStatus(testNS, *newTestPDB(map[string]string{"app": "typo"}, 2, 1), clientset); !errors.Is(err, ErrPDBSelectsNoPods) {
+		t.Fatalf("expected ErrPDBSelectsNoPods, got: %v", err)
+	}
+}
+
+func TestCheckPDBs(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	clientset := fake.NewSimpleClientset(&testDeployment, &testPod, newTestPDB(testLabels, 2, 1))
+	interval := 1 * time.Second
+	timeout := 5 * time.Second
+	if err := CheckPDBs([]string{testNS}, clientset, interval, timeout); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+}
+
+func TestCheckPDBsUncovered(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	clientset := fake.NewSimpleClientset(&testDeployment, &testPod)
+	interval := 1 * time.Second
+	timeout := 5 * time.Second
+	if err := CheckPDBs([]string{testNS}, clientset, interval, timeout); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+}
+
+func TestCheckPDBsBlocking(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	clientset := fake.NewSimpleClientset(&testDeployment, &testPod, newTestPDB(testLabels, 1, 1))
+	interval := 1 * time.Second
+	timeout := 2 * time.Second
+	if err := CheckPDBs([]string{testNS}, clientset, interval, timeout); err == nil {
+		t.Fatalf("expected error, got: %v", err)
+	}
+}
+
+func TestCheckPDBsNoDisruptions(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	pdb := newTestPDB(testLabels, 2, 2)
+	pdb.Status.ExpectedPods = 2
+	clientset := fake.NewSimpleClientset(&testDeployment, &testPod, pdb)
+	start := time.Now()
+	if err := CheckPDBs([]string{testNS}, clientset, time.Second, time.Minute); !errors.Is(err, ErrPDBNoDisruptions) {
+		t.Fatalf("expected ErrPDBNoDisruptions, got: %v", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Errorf("expected the check to fail without waiting for the timeout, took %v", time.Since(start))
+	}
+}
+
+func TestCheckPDBsNoNamespace(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	interval := 1 * time.Second
+	timeout := 5 * time.Second
+	if err := CheckPDBs([]string{}, fake.NewSimpleClientset(), interval, timeout); err != ErrNoNamespace {
+		t.Fatalf("expected ErrNoNamespace, got: %v", err)
+	}
+}
@@ -0,0 +1,206 @@
+package pod
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/vprashar2929/integration-test/pkg/logger"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+	utilexec "k8s.io/utils/exec"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	ErrInvalidExecAssertion = errors.New("invalid exec assertion")
+	ErrExecAssertionFailed  = errors.New("exec assertion failed")
+	ErrNoReadyPod           = errors.New("error no ready pod matches selector in namespace")
+	ErrInvalidInterval      = errors.New("interval or timeout is invalid")
+)
+
+// ExecAssertion runs a command in a container of a ready pod picked by selector and checks its outcome.
+// Stdout is a regular expression matched against the standard output of the command, surrounding whitespace
+// trimmed.
+type ExecAssertion struct {
+	Name      string   `json:"name"`
+	Namespace string   `json:"namespace"`
+	Selector  string   `json:"selector"`
+	Container string   `json:"container,omitempty"`
+	Command   []string `json:"command"`
+	ExitCode  int      `json:"exitCode"`
+	Stdout    string   `json:"stdout,omitempty"`
+}
+
+func (a ExecAssertion) String() string {
+	if a.Name != "" {
+		return a.Name
+	}
+	return strings.Join(a.Command, " ")
+}
+
+// ExecResult is what a command run inside a container produced
+type ExecResult struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int
+}
+
+// Executor runs commands inside containers, a non-zero exit code is reported in the result and not as an error
+type Executor interface {
+	Exec(ctx context.Context, namespace, pod, container string, command []string) (ExecResult, error)
+}
+
+// remoteExecutor runs commands through the pods/exec subresource
+type remoteExecutor struct {
+	config    *rest.Config
+	clientset kubernetes.Interface
+}
+
+// NewRemoteExecutor returns an executor using the pods/exec subresource of the API server
+func NewRemoteExecutor(config *rest.Config, clientset kubernetes.Interface) Executor {
+	return &remoteExecutor{config: config, clientset: clientset}
+}
+
+func (r *remoteExecutor) Exec(ctx context.Context, namespace, pod, container string, command []string) (ExecResult, error) {
+	request := r.clientset.CoreV1().RESTClient().Post().
+		Namespace(namespace).
+		Resource("pods").
+		Name(pod).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: container,
+			Command:   command,
+			Stdout:    true,
+			Stderr:    true,
+		}, scheme.ParameterCodec)
+	executor, err := remotecommand.NewSPDYExecutor(r.config, "POST", request.URL())
+	if err != nil {
+		return ExecResult{}, err
+	}
+	var stdout, stderr bytes.Buffer
+	err = executor.StreamWithContext(ctx, remotecommand.StreamOptions{Stdout: &stdout, Stderr: &stderr})
+	result := ExecResult{Stdout: stdout.String(), Stderr: stderr.String()}
+	var exitErr utilexec.ExitError
+	if errors.As(err, &exitErr) {
+		result.ExitCode = exitErr.ExitStatus()
+		return result, nil
+	}
+	return result, err
+}
+
+// LoadExecAssertions reads a YAML or JSON list of exec assertions
+func LoadExecAssertions(path string) ([]ExecAssertion, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var assertions []ExecAssertion
+	if err = yaml.UnmarshalStrict(data, &assertions); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidExecAssertion, path, err)
+	}
+	for _, assertion := range assertions {
+		if assertion.Namespace == "" || assertion.Selector == "" || len(assertion.Command) == 0 {
+			return nil, fmt.Errorf("%w: %s needs a namespace, selector and command", ErrInvalidExecAssertion, assertion)
+		}
+		if _, err = regexp.Compile(assertion.Stdout); err != nil {
+			return nil, fmt.Errorf("%w: %s stdout: %v", ErrInvalidExecAssertion, assertion, err)
+		}
+	}
+	return assertions, nil
+}
+
+func isPodReady(pod corev1.Pod) bool {
+	if pod.Status.Phase != corev1.PodRunning {
+		return false
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// selectPod returns the first ready pod matching the selector of the assertion
+func selectPod(assertion ExecAssertion, clientset kubernetes.Interface) (corev1.Pod, error) {
+	podList, err := clientset.CoreV1().Pods(assertion.Namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: assertion.Selector})
+	if err != nil {
+		logger.AppLog.LogError("error listing pods in namespace %s: %v\n", assertion.Namespace, err)
+		return corev1.Pod{}, ErrListingPods
+	}
+	for _, pod := range podList.Items {
+		if isPodReady(pod) {
+			return pod, nil
+		}
+	}
+	return corev1.Pod{}, fmt.Errorf("%w: %s in %s", ErrNoReadyPod, assertion.Selector, assertion.Namespace)
+}
+
+func checkExecAssertion(ctx context.Context, assertion ExecAssertion, executor Executor, clientset kubernetes.Interface) error {
+	pod, err := selectPod(assertion, clientset)
+	if err != nil {
+		return err
+	}
+	container := assertion.Container
+	if container == "" {
+		container = pod.Spec.Containers[0].Name
+	}
+	result, err := executor.Exec(ctx, pod.Namespace, pod.Name, container, assertion.Command)
+	if err != nil {
+		return err
+	}
+	if result.ExitCode != assertion.ExitCode {
+		return fmt.Errorf("%w: %s exited with %d in pod %s, expected %d, stderr: %s", ErrExecAssertionFailed, assertion, result.ExitCode, pod.Name, assertion.ExitCode, strings.TrimSpace(result.Stderr))
+	}
+	if assertion.Stdout != "" && !regexp.MustCompile(assertion.Stdout).MatchString(strings.TrimSpace(result.Stdout)) {
+		return fmt.Errorf("%w: %s output in pod %s does not match %q: %s", ErrExecAssertionFailed, assertion, pod.Name, assertion.Stdout, strings.TrimSpace(result.Stdout))
+	}
+	logger.AppLog.LogInfo("exec assertion %s passed in pod %s container %s\n", assertion, pod.Name, container)
+	return nil
+}
+
+// CheckExecAssertions runs the assertions targeting one of namespaces, the others belong to other runs
+func CheckExecAssertions(namespaces []string, assertions []ExecAssertion, executor Executor, clientset kubernetes.Interface, interval, timeout time.Duration) error {
+	logger.AppLog.LogInfo("Begin exec assertion validation")
+
+	var err error
+	if interval <= 0 || timeout <= 0 {
+		return ErrInvalidInterval
+	}
+	monitored := make(map[string]bool)
+	for _, namespace := range namespaces {
+		monitored[namespace] = true
+	}
+	for _, assertion := range assertions {
+		if !monitored[assertion.Namespace] {
+			logger.AppLog.LogDebug("skipping exec assertion %s outside the checked namespaces\n", assertion)
+			continue
+		}
+		deadline := time.Now().Add(timeout)
+		for time.Now().Before(deadline) {
+			ctx, cancel := context.WithDeadline(context.Background(), deadline)
+			err = checkExecAssertion(ctx, assertion, executor, clientset)
+			cancel()
+			if err == nil {
+				break
+			}
+			time.Sleep(interval)
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timeout running exec assertion %s in namespace %s, error: %v", assertion, assertion.Namespace, err)
+		}
+	}
+
+	logger.AppLog.LogInfo("End exec assertion validation")
+	return nil
+}
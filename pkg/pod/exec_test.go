@@ -0,0 +1,131 @@
+package pod
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vprashar2929/integration-test/pkg/logger"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// fakeExecutor answers commands from a table keyed by the joined command line
+type fakeExecutor struct {
+	results map[string]ExecResult
+	calls   []string
+}
+
+func (f *fakeExecutor) Exec(ctx context.Context, namespace, pod, container string, command []string) (ExecResult, error) {
+	f.calls = append(f.calls, namespace+"/"+pod+"/"+container)
+	result, ok := f.results[strings.Join(command, " ")]
+	if !ok {
+		return ExecResult{}, errors.New("command not found")
+	}
+	return result, nil
+}
+
+func newReadyPod() *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "db-0", Namespace: testNS, Labels: map[string]string{"app": "db"}},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "postgres"}, {Name: "exporter"}}},
+		Status: corev1.PodStatus{
+			Phase:      corev1.PodRunning,
+			Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+		},
+	}
+}
+
+func newTestExecutor() *fakeExecutor {
+	return &fakeExecutor{results: map[string]ExecResult{
+		"migrate version":     {Stdout: "version 42\n"},
+		"test -f /data/ready": {ExitCode: 1, Stderr: "no such file"},
+	}}
+}
+
+func TestCheckExecAssertion(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	clientset := fake.NewSimpleClientset(newReadyPod())
+	executor := newTestExecutor()
+	assertion := ExecAssertion{Namespace: testNS, Selector: "app=db", Command: []string{"migrate", "version"}, Stdout: `^version 4\d$`}
+	if err := checkExecAssertion(context.TODO(), assertion, executor, clientset); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	if executor.calls[0] != testNS+"/db-0/postgres" {
+		t.Fatalf("expected first container of db-0, got: %s", executor.calls[0])
+	}
+	assertion.Stdout = "version 43"
+	if err := checkExecAssertion(context.TODO(), assertion, executor, clientset); !errors.Is(err, ErrExecAssertionFailed) {
+		t.Fatalf("expected ErrExecAssertionFailed, got: %v", err)
+	}
+	assertion = ExecAssertion{Namespace: testNS, Selector: "app=db", Container: "exporter", Command: []string{"test", "-f", "/data/ready"}}
+	if err := checkExecAssertion(context.TODO(), assertion, executor, clientset); !errors.Is(err, ErrExecAssertionFailed) {
+		t.Fatalf("expected ErrExecAssertionFailed, got: %v", err)
+	}
+	assertion.ExitCode = 1
+	if err := checkExecAssertion(context.TODO(), assertion, executor, clientset); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+}
+
+func TestCheckExecAssertionNoReadyPod(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	pod := newReadyPod()
+	pod.Status.Conditions[0].Status = corev1.ConditionFalse
+	assertion := ExecAssertion{Namespace: testNS, Selector: "app=db", Command: []string{"migrate", "version"}}
+	err := checkExecAssertion(context.TODO(), assertion, newTestExecutor(), fake.NewSimpleClientset(pod))
+	if !errors.Is(err, ErrNoReadyPod) {
+		t.Fatalf("expected ErrNoReadyPod, got: %v", err)
+	}
+}
+
+func TestLoadExecAssertions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "assertions.yaml")
+	content := `- name: migration version
+  namespace: test-namespace
+  selector: app=db
+  command: ["migrate", "version"]
+  stdout: "version 42"
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	assertions, err := LoadExecAssertions(path)
+	if err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	if len(assertions) != 1 || assertions[0].Name != "migration version" || len(assertions[0].Command) != 2 {
+		t.Fatalf("expected one migration version assertion, got: %+v", assertions)
+	}
+	if err := os.WriteFile(path, []byte("- namespace: test-namespace\n  command: [ls]\n"), 0o600); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	if _, err = LoadExecAssertions(path); !errors.Is(err, ErrInvalidExecAssertion) {
+		t.Fatalf("expected ErrInvalidExecAssertion, got: %v", err)
+	}
+}
+
+func TestCheckExecAssertions(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	clientset := fake.NewSimpleClientset(newReadyPod())
+	assertions := []ExecAssertion{{Namespace: testNS, Selector: "app=db", Command: []string{"migrate", "version"}, Stdout: "version 42"}}
+	interval := 1 * time.Second
+	timeout := 5 * time.Second
+	if err := CheckExecAssertions([]string{testNS}, assertions, newTestExecutor(), clientset, interval, timeout); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	assertions[0].Stdout = "version 43"
+	timeout = 2 * time.Second
+	if err := CheckExecAssertions([]string{testNS}, assertions, newTestExecutor(), clientset, interval, timeout); err == nil {
+		t.Fatalf("expected error, got: %v", err)
+	}
+	executor := newTestExecutor()
+	if err := CheckExecAssertions([]string{"other-namespace"}, assertions, executor, clientset, interval, timeout); err != nil || len(executor.calls) != 0 {
+		t.Fatalf("expected the assertion of another namespace to be skipped, got: %v %v", executor.calls, err)
+	}
+}
@@ -0,0 +1,178 @@
+package pod
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/vprashar2929/integration-test/pkg/logger"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const defaultRegistry = "docker.io"
+
+// dockerHubAliases are other host names of Docker Hub found in image references
+var dockerHubAliases = map[string]bool{
+	"index.docker.io":         true,
+	"registry-1.docker.io":    true,
+	"registry.hub.docker.com": true,
+}
+
+var (
+	ErrImageLatestTag        = errors.New("image uses the latest tag")
+	ErrImageRegistry         = errors.New("image registry not allowed")
+	ErrImageNotPinned        = errors.New("image not pinned by digest")
+	ErrImageDigestMismatch   = errors.New("running image digest does not match expected digest")
+	ErrInvalidExpectedDigest = errors.New("invalid expected image digest")
+	ErrImagePolicy           = errors.New("pods violate the image policy")
+)
+
+// ImagePolicy is enforced on the containers of every pod of the monitored namespaces. Expected digests are keyed
+// by the image name as written in the pod spec without tag or digest.
+type ImagePolicy struct {
+	DisallowLatest    bool
+	AllowedRegistries []string
+	RequireDigest     bool
+	ExpectedDigests   map[string]string
+}
+
+// ParseExpectedDigest parses <image>@<digest> as given on the command line
+func ParseExpectedDigest(value string) (string, string, error) {
+	name, digest, found := strings.Cut(value, "@")
+	if !found || name == "" || !strings.Contains(digest, ":") {
+		return "", "", fmt.Errorf("%w: %q, expected <image>@<algorithm>:<hex>", ErrInvalidExpectedDigest, value)
+	}
+	return name, digest, nil
+}
+
+// imageReference is a container image split into its parts, name excludes tag and digest
+type imageReference struct {
+	Name     string
+	Registry string
+	Tag      string
+	Digest   string
+}
+
+// normalizeRegistry maps the Docker Hub aliases to docker.io so they match each other
+func normalizeRegistry(registry string) string {
+	if dockerHubAliases[registry] {
+		return defaultRegistry
+	}
+	return registry
+}
+
+func parseImage(image string) imageReference {
+	ref := imageReference{Name: image}
+	if name, digest, found := strings.Cut(image, "@"); found {
+		ref.Name, ref.Digest = name, digest
+	}
+	// a colon after the last slash separates the tag, one before it belongs to a registry port
+	if i := strings.LastIndex(ref.Name, ":"); i > strings.LastIndex(ref.Name, "/") {
+		ref.Name, ref.Tag = ref.Name[:i], ref.Name[i+1:]
+	}
+	ref.Registry = defaultRegistry
+	if first, _, found := strings.Cut(ref.Name, "/"); found && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		ref.Registry = normalizeRegistry(first)
+	}
+	return ref
+}
+
+// runningDigest returns the repository digest from a container status imageID, empty when the runtime only
+// reports the local image id
+func runningDigest(imageID string) string {
+	if i := strings.LastIndex(imageID, "@"); i >= 0 {
+		return imageID[i+1:]
+	}
+	return ""
+}
+
+func checkImage(image string, policy *ImagePolicy) error {
+	ref := parseImage(image)
+	if policy.DisallowLatest && ref.Digest == "" && (ref.Tag == "" || ref.Tag == "latest") {
+		return fmt.Errorf("%w: %s", ErrImageLatestTag, image)
+	}
+	if len(policy.AllowedRegistries) > 0 {
+		allowed := false
+		for _, registry := range policy.AllowedRegistries {
+			if ref.Registry == normalizeRegistry(registry) {
+				allowed = true
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("%w: %s from %s", ErrImageRegistry, image, ref.Registry)
+		}
+	}
+	if policy.RequireDigest && ref.Digest == "" {
+		return fmt.Errorf("%w: %s", ErrImageNotPinned, image)
+	}
+	return nil
+}
+
+func checkImagePolicy(pod corev1.Pod, policy *ImagePolicy) error {
+	images := make(map[string]string)
+	for _, container := range append(append([]corev1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...) {
+		if err := checkImage(container.Image, policy); err != nil {
+			return fmt.Errorf("pod %s container %s: %w", pod.Name, container.Name, err)
+		}
+		images[container.Name] = container.Image
+	}
+	if len(policy.ExpectedDigests) == 0 {
+		return nil
+	}
+	for _, status := range append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...) {
+		name := parseImage(images[status.Name]).Name
+		expected, ok := policy.ExpectedDigests[name]
+		if !ok {
+			continue
+		}
+		// containers that have not started yet have no image to compare
+		if status.ImageID == "" {
+			continue
+		}
+		if running := runningDigest(status.ImageID); running != expected {
+			return fmt.Errorf("%w: pod %s container %s runs %s@%s, expected %s", ErrImageDigestMismatch, pod.Name, status.Name, name, running, expected)
+		}
+	}
+	return nil
+}
+
+// CheckImages enforces the policy on every pod of the namespaces that has not completed. An image that breaks
+// the policy stays in the pod spec until the workload is changed, so the pods are checked once without waiting
+// and every violation is reported together.
+func CheckImages(namespaces []string, policy *ImagePolicy, clientset kubernetes.Interface) error {
+	logger.AppLog.LogInfo("Begin image policy validation")
+
+	if len(namespaces) == 0 {
+		return ErrNoNamespace
+	}
+	var violations []string
+	for _, namespace := range namespaces {
+		if namespace == "" {
+			logger.AppLog.LogError("Invalid namespace provided.")
+			continue
+		}
+		podList, err := clientset.CoreV1().Pods(namespace).List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			logger.AppLog.LogError("error listing pods in namespace %s: %v\n", namespace, err)
+			return ErrListingPods
+		}
+		for _, pod := range podList.Items {
+			if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+				continue
+			}
+			if err = checkImagePolicy(pod, policy); err != nil {
+				logger.AppLog.LogError("%v in namespace %s\n", err, namespace)
+				violations = append(violations, err.Error()+" in namespace "+namespace)
+			}
+		}
+	}
+	if len(violations) > 0 {
+		return fmt.Errorf("%w: %s", ErrImagePolicy, strings.Join(violations, "; "))
+	}
+
+	logger.AppLog.LogInfo("End image policy validation")
+	return nil
+}
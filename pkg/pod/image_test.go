@@ -0,0 +1,105 @@
+package pod
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/vprashar2929/integration-test/pkg/logger"
+	corev1 "k8s.io/api/core/v1"
+
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var testDigest = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+func TestParseImage(t *testing.T) {
+	tests := map[string]imageReference{
+		"nginx":                         {Name: "nginx", Registry: "docker.io"},
+		"quay.io/foo/bar:v1":            {Name: "quay.io/foo/bar", Registry: "quay.io", Tag: "v1"},
+		"localhost:5000/app:1.0":        {Name: "localhost:5000/app", Registry: "localhost:5000", Tag: "1.0"},
+		"quay.io/foo/bar@" + testDigest: {Name: "quay.io/foo/bar", Registry: "quay.io", Digest: testDigest},
+		"index.docker.io/library/nginx": {Name: "index.docker.io/library/nginx", Registry: "docker.io"},
+	}
+	for image, expected := range tests {
+		if ref := parseImage(image); ref != expected {
+			t.Errorf("expected %+v for %s, got: %+v", expected, image, ref)
+		}
+	}
+}
+
+func TestCheckImageDockerHubAliases(t *testing.T) {
+	policy := &ImagePolicy{AllowedRegistries: []string{"index.docker.io"}}
+	for _, image := range []string{"nginx:1.25", "docker.io/library/nginx:1.25", "registry-1.docker.io/library/nginx:1.25"} {
+		if err := checkImage(image, policy); err != nil {
+			t.Fatalf("expected nil for %s, got: %v", image, err)
+		}
+	}
+}
+
+func TestCheckImage(t *testing.T) {
+	policy := &ImagePolicy{DisallowLatest: true, AllowedRegistries: []string{"quay.io"}}
+	if err := checkImage("quay.io/foo/bar:v1", policy); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	if err := checkImage(testImage, policy); !errors.Is(err, ErrImageLatestTag) {
+		t.Fatalf("expected ErrImageLatestTag, got: %v", err)
+	}
+	if err := checkImage("quay.io/foo/bar", policy); !errors.Is(err, ErrImageLatestTag) {
+		t.Fatalf("expected ErrImageLatestTag for untagged image, got: %v", err)
+	}
+	if err := checkImage("docker.io/library/nginx:1.25", policy); !errors.Is(err, ErrImageRegistry) {
+		t.Fatalf("expected ErrImageRegistry, got: %v", err)
+	}
+	policy = &ImagePolicy{RequireDigest: true}
+	if err := checkImage("quay.io/foo/bar:v1", policy); !errors.Is(err, ErrImageNotPinned) {
+		t.Fatalf("expected ErrImageNotPinned, got: %v", err)
+	}
+}
+
+func TestCheckImagePolicyExpectedDigest(t *testing.T) {
+	pod := *testPodList.Items[0].DeepCopy()
+	pod.Status.ContainerStatuses[0].Name = testContainer
+	pod.Status.ContainerStatuses[0].ImageID = "quay.io/foo/bar@" + testDigest
+	policy := &ImagePolicy{ExpectedDigests: map[string]string{"quay.io/foo/bar": testDigest}}
+	if err := checkImagePolicy(pod, policy); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	pod.Status.ContainerStatuses[0].ImageID = "quay.io/foo/bar@sha256:ffff"
+	if err := checkImagePolicy(pod, policy); !errors.Is(err, ErrImageDigestMismatch) {
+		t.Fatalf("expected ErrImageDigestMismatch, got: %v", err)
+	}
+}
+
+func TestParseExpectedDigest(t *testing.T) {
+	name, digest, err := ParseExpectedDigest("quay.io/foo/bar@" + testDigest)
+	if err != nil || name != "quay.io/foo/bar" || digest != testDigest {
+		t.Fatalf("expected quay.io/foo/bar and digest, got: %s %s %v", name, digest, err)
+	}
+	if _, _, err := ParseExpectedDigest("quay.io/foo/bar:v1"); !errors.Is(err, ErrInvalidExpectedDigest) {
+		t.Fatalf("expected ErrInvalidExpectedDigest, got: %v", err)
+	}
+}
+
+func TestCheckImages(t *testing.T) {
+	clientset := fake.NewSimpleClientset(&testPodList)
+	logger.NewLogger(logger.LevelInfo)
+	err := CheckImages([]string{testNS}, &ImagePolicy{DisallowLatest: true}, clientset)
+	if !errors.Is(err, ErrImagePolicy) || !strings.Contains(err.Error(), ErrImageLatestTag.Error()) {
+		t.Fatalf("expected ErrImagePolicy for the latest tag, got: %v", err)
+	}
+	if err = CheckImages([]string{testNS}, &ImagePolicy{}, clientset); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	if err = CheckImages([]string{}, &ImagePolicy{}, clientset); err != ErrNoNamespace {
+		t.Fatalf("expected ErrNoNamespace, got: %v", err)
+	}
+}
+
+func TestCheckImagePolicyInitContainers(t *testing.T) {
+	pod := *testPodList.Items[0].DeepCopy()
+	pod.Spec.InitContainers = []corev1.Container{{Name: "init", Image: "docker.io/busybox:latest"}}
+	if err := checkImagePolicy(pod, &ImagePolicy{AllowedRegistries: []string{"quay.io"}}); !errors.Is(err, ErrImageRegistry) {
+		t.Fatalf("expected ErrImageRegistry, got: %v", err)
+	}
+}
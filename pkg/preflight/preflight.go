@@ -0,0 +1,319 @@
+package preflight
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/vprashar2929/integration-test/pkg/logger"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	ErrNoNamespace        = errors.New("no namespace provided")
+	ErrAccessReview       = errors.New("error creating selfsubjectaccessreview")
+	ErrMissingPermissions = errors.New("service account is missing permissions")
+)
+
+// Rule is a permission a checker needs. ClusterScoped rules are reviewed once without a namespace and rules
+// with a Namespace are reviewed there instead of in the monitored namespaces.
+type Rule struct {
+	Checker       string
+	Group         string
+	Resource      string
+	Verbs         []string
+	ClusterScoped bool
+	Namespace     string
+}
+
+// Missing is a single verb on a resource the service account is not allowed to use, the rule carries the
+// namespace it was reviewed in
+type Missing struct {
+	Rule
+	Verb string
+}
+
+var readVerbs = []string{"get", "list"}
+
+// checkerRules lists what every built-in checker reads, including the lookups done through shared packages
+// such as pod and service
+var checkerRules = []Rule{
+	{Checker: "references", Group: "", Resource: "configmaps", Verbs: []string{"get"}},
+	{Checker: "references", Group: "", Resource: "secrets", Verbs: []string{"get"}},
+	{Checker: "deployment", Group: "apps", Resource: "deployments", Verbs: readVerbs},
+	{Checker: "deployment", Group: "apps", Resource: "replicasets", Verbs: readVerbs},
+	{Checker: "deployment", Group: "", Resource: "pods", Verbs: readVerbs},
+	{Checker: "deployment", Group: "", Resource: "pods/log", Verbs: []string{"get"}},
+	{Checker: "deployment", Group: "autoscaling", Resource: "horizontalpodautoscalers", Verbs: readVerbs},
+	{Checker: "statefulset", Group: "apps", Resource: "statefulsets", Verbs: readVerbs},
+	{Checker: "statefulset", Group: "", Resource: "persistentvolumeclaims", Verbs: readVerbs},
+	{Checker: "statefulset", Group: "", Resource: "pods", Verbs: readVerbs},
+	{Checker: "statefulset", Group: "", Resource: "pods/log", Verbs: []string{"get"}},
+	{Checker: "statefulset", Group: "autoscaling", Resource: "horizontalpodautoscalers", Verbs: readVerbs},
+	{Checker: "service", Group: "", Resource: "services", Verbs: readVerbs},
+	{Checker: "service", Group: "", Resource: "endpoints", Verbs: readVerbs},
+	{Checker: "service", Group: "discovery.k8s.io", Resource: "endpointslices", Verbs: readVerbs},
+	{Checker: "service", Group: "", Resource: "pods", Verbs: []string{"get"}},
+	{Checker: "daemonset", Group: "apps", Resource: "daemonsets", Verbs: readVerbs},
+	{Checker: "daemonset", Group: "", Resource: "nodes", Verbs: readVerbs, ClusterScoped: true},
+	{Checker: "daemonset", Group: "", Resource: "pods", Verbs: readVerbs},
+	{Checker: "daemonset", Group: "", Resource: "pods/log", Verbs: []string{"get"}},
+	{Checker: "hpa", Group: "autoscaling", Resource: "horizontalpodautoscalers", Verbs: readVerbs},
+	{Checker: "pdb", Group: "policy", Resource: "poddisruptionbudgets", Verbs: readVerbs},
+	{Checker: "ingress", Group: "networking.k8s.io", Resource: "ingresses", Verbs: readVerbs},
+	{Checker: "route", Group: "route.openshift.io", Resource: "routes", Verbs: readVerbs},
+	{Checker: "gateway", Group: "gateway.networking.k8s.io", Resource: "gateways", Verbs: readVerbs},
+	{Checker: "gateway", Group: "gateway.networking.k8s.io", Resource: "httproutes", Verbs: readVerbs},
+	{Checker: "olm", Group: "operators.coreos.com", Resource: "subscriptions", Verbs: readVerbs},
+	{Checker: "olm", Group: "operators.coreos.com", Resource: "clusterserviceversions", Verbs: readVerbs},
+	{Checker: "olm", Group: "operators.coreos.com", Resource: "installplans", Verbs: readVerbs},
+	{Checker: "helm", Group: "", Resource: "secrets", Verbs: readVerbs},
+	{Checker: "argocd", Group: "argoproj.io", Resource: "applications", Verbs: readVerbs},
+}
+
+// clusterRules lists what the cluster checker reads, it looks at kube-system whatever namespaces are monitored
+var clusterRules = []Rule{
+	{Checker: "cluster", Group: "", Resource: "nodes", Verbs: readVerbs, ClusterScoped: true},
+	{Checker: "cluster", Group: "", Resource: "pods", Verbs: readVerbs, Namespace: "kube-system"},
+	{Checker: "cluster", Group: "", Resource: "pods/log", Verbs: []string{"get"}, Namespace: "kube-system"},
+	{Checker: "cluster", Group: "apps", Resource: "deployments", Verbs: readVerbs, Namespace: "kube-system"},
+	{Checker: "cluster", Group: "apps", Resource: "replicasets", Verbs: readVerbs, Namespace: "kube-system"},
+}
+
+// optionalRules lists what the checkers that only run when enabled by a flag read, by checker name
+var optionalRules = map[string][]Rule{
+	"cluster": clusterRules,
+	"audit": {
+		{Checker: "audit", Group: "apps", Resource: "deployments", Verbs: readVerbs},
+		{Checker: "audit", Group: "apps", Resource: "statefulsets", Verbs: readVerbs},
+		{Checker: "audit", Group: "apps", Resource: "daemonsets", Verbs: readVerbs},
+	},
+	"image": {
+		{Checker: "image", Group: "", Resource: "pods", Verbs: []string{"list"}},
+	},
+	"probe": {
+		{Checker: "probe", Group: "", Resource: "pods", Verbs: readVerbs},
+	},
+	"proxy": {
+		{Checker: "probe", Group: "", Resource: "pods/proxy", Verbs: []string{"get"}},
+	},
+	"portforward": {
+		{Checker: "probe", Group: "", Resource: "pods/portforward", Verbs: []string{"create"}},
+	},
+}
+
+// ExecRules returns what exec assertions need in each namespace they run in, which may differ from the monitored
+// namespaces
+func ExecRules(namespaces []string) []Rule {
+	var rules []Rule
+	seen := make(map[string]bool)
+	for _, namespace := range namespaces {
+		if seen[namespace] {
+			continue
+		}
+		seen[namespace] = true
+		rules = append(rules,
+			Rule{Checker: "exec", Group: "", Resource: "pods", Verbs: []string{"list"}, Namespace: namespace},
+			Rule{Checker: "exec", Group: "", Resource: "pods/exec", Verbs: []string{"create"}, Namespace: namespace},
+		)
+	}
+	return rules
+}
+
+// Rules returns the permissions needed by the built-in checkers, the enabled optional checkers and the
+// configured custom resource checks
+func Rules(customResources []schema.GroupVersionResource, optional ...string) []Rule {
+	rules := append([]Rule{}, checkerRules...)
+	for _, checker := range optional {
+		rules = append(rules, optionalRules[checker]...)
+	}
+	for _, gvr := range customResources {
+		rules = append(rules, Rule{Checker: "customresource", Group: gvr.Group, Resource: gvr.Resource, Verbs: readVerbs})
+	}
+	return rules
+}
+
+func checkAccess(namespace, group, resource, verb string, clientset kubernetes.Interface) (bool, error) {
+	// rules name subresources the way rbac does, as pods/log
+	resourceName, subresource, _ := strings.Cut(resource, "/")
+	review := &authorizationv1.SelfSubjectAccessReview{
+		Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace:   namespace,
+				Verb:        verb,
+				Group:       group,
+				Resource:    resourceName,
+				Subresource: subresource,
+			},
+		},
+	}
+	result, err := clientset.AuthorizationV1().SelfSubjectAccessReviews().Create(context.TODO(), review, metav1.CreateOptions{})
+	if err != nil {
+		logger.AppLog.LogError("error reviewing access to %s %s in namespace %s: %v\n", verb, resource, namespace, err)
+		return false, ErrAccessReview
+	}
+	return result.Status.Allowed, nil
+}
+
+// GetMissingPermissions reviews every verb of every rule in each namespace and returns the ones that are denied
+func GetMissingPermissions(namespaces []string, rules []Rule, clientset kubernetes.Interface) ([]Missing, error) {
+	if len(namespaces) == 0 {
+		return nil, ErrNoNamespace
+	}
+	var missing []Missing
+	for _, rule := range rules {
+		scopes := namespaces
+		if rule.ClusterScoped {
+			scopes = []string{""}
+		} else if rule.Namespace != "" {
+			scopes = []string{rule.Namespace}
+		}
+		for _, namespace := range scopes {
+			if namespace == "" && !rule.ClusterScoped {
+				logger.AppLog.LogError("Invalid namespace provided.")
+				continue
+			}
+			for _, verb := range rule.Verbs {
+				allowed, err := checkAccess(namespace, rule.Group, rule.Resource, verb, clientset)
+				if err != nil {
+					return nil, err
+				}
+				if !allowed {
+					scoped := rule
+					scoped.Namespace = namespace
+					missing = append(missing, Missing{Rule: scoped, Verb: verb})
+				}
+			}
+		}
+	}
+	return missing, nil
+}
+
+// FormatMissingPermissions renders the missing permissions as a table
+func FormatMissingPermissions(missing []Missing) string {
+	var buf bytes.Buffer
+	writer := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "CHECKER\tNAMESPACE\tVERB\tAPIGROUP\tRESOURCE")
+	for _, m := range missing {
+		namespace := m.Namespace
+		if m.ClusterScoped {
+			namespace = "(cluster)"
+		}
+		group := m.Group
+		if group == "" {
+			group = "core"
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n", m.Checker, namespace, m.Verb, group, m.Resource)
+	}
+	writer.Flush()
+	return buf.String()
+}
+
+// policyRules merges rules sharing an api group and verbs so the generated roles stay short
+func policyRules(rules []Rule) []rbacv1.PolicyRule {
+	var policies []rbacv1.PolicyRule
+	index := make(map[string]int)
+	seen := make(map[string]bool)
+	for _, rule := range rules {
+		key := fmt.Sprintf("%s%v", rule.Group, rule.Verbs)
+		if seen[key+"/"+rule.Resource] {
+			continue
+		}
+		seen[key+"/"+rule.Resource] = true
+		if i, ok := index[key]; ok {
+			policies[i].Resources = append(policies[i].Resources, rule.Resource)
+			continue
+		}
+		index[key] = len(policies)
+		policies = append(policies, rbacv1.PolicyRule{
+			APIGroups: []string{rule.Group},
+			Resources: []string{rule.Resource},
+			Verbs:     append([]string{}, rule.Verbs...),
+		})
+	}
+	sort.SliceStable(policies, func(i, j int) bool { return policies[i].APIGroups[0] < policies[j].APIGroups[0] })
+	return policies
+}
+
+// GenerateRBAC returns a List with a Role per namespace for namespaced rules and a ClusterRole for cluster
+// scoped ones, the minimal set of permissions needed by the given rules, each bound to the service account
+func GenerateRBAC(name string, serviceAccount rbacv1.Subject, namespaces []string, rules []Rule) ([]byte, error) {
+	var clusterScoped []Rule
+	var roleNamespaces []string
+	rulesByNamespace := make(map[string][]Rule)
+	addRule := func(namespace string, rule Rule) {
+		if _, ok := rulesByNamespace[namespace]; !ok {
+			roleNamespaces = append(roleNamespaces, namespace)
+		}
+		rulesByNamespace[namespace] = append(rulesByNamespace[namespace], rule)
+	}
+	for _, rule := range rules {
+		switch {
+		case rule.ClusterScoped:
+			clusterScoped = append(clusterScoped, rule)
+		case rule.Namespace != "":
+			addRule(rule.Namespace, rule)
+		default:
+			for _, namespace := range namespaces {
+				if namespace != "" {
+					addRule(namespace, rule)
+				}
+			}
+		}
+	}
+	var items []interface{}
+	for _, namespace := range roleNamespaces {
+		role := rbacv1.Role{
+			TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "Role"},
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Rules:      policyRules(rulesByNamespace[namespace]),
+		}
+		binding := rbacv1.RoleBinding{
+			TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "RoleBinding"},
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: name},
+			Subjects:   []rbacv1.Subject{serviceAccount},
+		}
+		items = append(items, role, binding)
+	}
+	if len(clusterScoped) > 0 {
+		clusterRole := rbacv1.ClusterRole{
+			TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "ClusterRole"},
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Rules:      policyRules(clusterScoped),
+		}
+		binding := rbacv1.ClusterRoleBinding{
+			TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "ClusterRoleBinding"},
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: name},
+			Subjects:   []rbacv1.Subject{serviceAccount},
+		}
+		items = append(items, clusterRole, binding)
+	}
+	return yaml.Marshal(map[string]interface{}{"apiVersion": "v1", "kind": "List", "items": items})
+}
+
+func CheckPermissions(namespaces []string, rules []Rule, clientset kubernetes.Interface) error {
+	logger.AppLog.LogInfo("Begin RBAC preflight")
+
+	missing, err := GetMissingPermissions(namespaces, rules, clientset)
+	if err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		logger.AppLog.LogError("service account is missing %d permissions:\n%s", len(missing), FormatMissingPermissions(missing))
+		return ErrMissingPermissions
+	}
+
+	logger.AppLog.LogInfo("End RBAC preflight")
+	return nil
+}
@@ -0,0 +1,156 @@
+package preflight
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/vprashar2929/integration-test/pkg/logger"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var (
+	testNS             = "test-namespace"
+	testServiceAccount = rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Namespace: testNS, Name: "integration-test"}
+)
+
+// newReviewClient returns a clientset whose access reviews deny every resource listed in denied
+func newReviewClient(denied ...string) *fake.Clientset {
+	clientset := fake.NewSimpleClientset()
+	clientset.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+		review.Status.Allowed = true
+		attributes := review.Spec.ResourceAttributes
+		resource := attributes.Resource
+		if attributes.Subresource != "" {
+			resource += "/" + attributes.Subresource
+		}
+		for _, deniedResource := range denied {
+			if resource == deniedResource {
+				review.Status.Allowed = false
+			}
+		}
+		return true, review, nil
+	})
+	return clientset
+}
+
+func TestGetMissingPermissions(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	missing, err := GetMissingPermissions([]string{testNS}, Rules(nil), newReviewClient("nodes", "routes"))
+	if err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	// get and list for both resources
+	if len(missing) != 4 {
+		t.Fatalf("expected 4 missing permissions, got: %v", missing)
+	}
+	for _, m := range missing {
+		if m.Resource == "nodes" && m.Namespace != "" {
+			t.Errorf("expected nodes to be reviewed cluster wide, got namespace %q", m.Namespace)
+		}
+	}
+}
+
+func TestGetMissingPermissionsNoNamespace(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	if _, err := GetMissingPermissions([]string{}, Rules(nil), newReviewClient()); err != ErrNoNamespace {
+		t.Fatalf("expected ErrNoNamespace, got: %v", err)
+	}
+}
+
+func TestFormatMissingPermissions(t *testing.T) {
+	table := FormatMissingPermissions([]Missing{
+		{Rule: Rule{Checker: "daemonset", Resource: "nodes", ClusterScoped: true}, Verb: "list"},
+		{Rule: Rule{Checker: "route", Group: "route.openshift.io", Resource: "routes", Namespace: testNS}, Verb: "get"},
+	})
+	lines := strings.Split(strings.TrimSpace(table), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected header and 2 rows, got: %q", table)
+	}
+	if !strings.Contains(lines[1], "(cluster)") || !strings.Contains(lines[1], "core") {
+		t.Errorf("expected cluster scoped core row, got: %q", lines[1])
+	}
+}
+
+func TestGenerateRBAC(t *testing.T) {
+	rules := Rules([]schema.GroupVersionResource{{Group: "example.com", Version: "v1", Resource: "widgets"}})
+	out, err := GenerateRBAC("integration-test", testServiceAccount, []string{testNS, "other"}, rules)
+	if err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	manifest := string(out)
+	if strings.Count(manifest, "\n  kind: Role\n") != 2 || strings.Count(manifest, "\n  kind: ClusterRole\n") != 1 {
+		t.Fatalf("expected 2 roles and 1 clusterrole, got:\n%s", manifest)
+	}
+	if strings.Count(manifest, "\n  kind: RoleBinding\n") != 2 || strings.Count(manifest, "\n  kind: ClusterRoleBinding\n") != 1 {
+		t.Fatalf("expected 2 rolebindings and 1 clusterrolebinding, got:\n%s", manifest)
+	}
+	if !strings.Contains(manifest, "pods/log") {
+		t.Errorf("expected pods/log in the monitored namespaces, got:\n%s", manifest)
+	}
+	if !strings.Contains(manifest, "example.com") || !strings.Contains(manifest, "widgets") {
+		t.Errorf("expected custom resource rule, got:\n%s", manifest)
+	}
+}
+
+func TestPolicyRulesMergesGroups(t *testing.T) {
+	policies := policyRules([]Rule{
+		{Group: "apps", Resource: "deployments", Verbs: readVerbs},
+		{Group: "apps", Resource: "statefulsets", Verbs: readVerbs},
+		{Group: "apps", Resource: "deployments", Verbs: readVerbs},
+		{Group: "", Resource: "pods", Verbs: readVerbs},
+	})
+	if len(policies) != 2 || len(policies[1].Resources) != 2 {
+		t.Fatalf("expected core and apps rules, got: %v", policies)
+	}
+}
+
+func TestCheckPermissions(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	if err := CheckPermissions([]string{testNS}, Rules(nil), newReviewClient()); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	if err := CheckPermissions([]string{testNS}, Rules(nil), newReviewClient("deployments")); err != ErrMissingPermissions {
+		t.Fatalf("expected ErrMissingPermissions, got: %v", err)
+	}
+}
+
+func TestGenerateRBACClusterChecks(t *testing.T) {
+	out, err := GenerateRBAC("integration-test", testServiceAccount, []string{testNS}, Rules(nil, "cluster"))
+	if err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	if !strings.Contains(string(out), "namespace: kube-system") {
+		t.Fatalf("expected a role in kube-system, got:\n%s", out)
+	}
+}
+
+func TestGetMissingPermissionsClusterChecks(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	missing, err := GetMissingPermissions([]string{testNS}, clusterRules, newReviewClient("pods/log"))
+	if err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	if len(missing) != 1 || missing[0].Namespace != "kube-system" {
+		t.Fatalf("expected pods/log missing in kube-system, got: %v", missing)
+	}
+}
+
+func TestExecRules(t *testing.T) {
+	rules := ExecRules([]string{"db", "db", "cache"})
+	if len(rules) != 4 || rules[0].Namespace != "db" || rules[2].Namespace != "cache" {
+		t.Fatalf("expected pods and pods/exec rules for db and cache, got: %v", rules)
+	}
+	out, err := GenerateRBAC("integration-test", testServiceAccount, []string{testNS}, append(Rules(nil), rules...))
+	if err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	if !strings.Contains(string(out), "namespace: cache") || strings.Count(string(out), "pods/exec") != 2 {
+		t.Fatalf("expected pods/exec in the assertion namespaces only, got:\n%s", out)
+	}
+}
@@ -0,0 +1,127 @@
+package probe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+)
+
+// forwardSettleTime is how long a forwarded connection must stay open for the remote port to count as open
+const forwardSettleTime = 500 * time.Millisecond
+
+var ErrPortClosed = errors.New("connection to pod port closed by port-forward")
+
+// portForwardTransport reaches pods through the pods/portforward subresource over SPDY, which works from
+// outside the cluster network
+type portForwardTransport struct {
+	config    *rest.Config
+	clientset kubernetes.Interface
+}
+
+// NewPortForwardTransport returns a transport forwarding a local port to the pod for every connection
+func NewPortForwardTransport(config *rest.Config, clientset kubernetes.Interface) Transport {
+	return &portForwardTransport{config: config, clientset: clientset}
+}
+
+// forwardedConn stops the port-forward once the connection going through it is closed
+type forwardedConn struct {
+	net.Conn
+	stop chan struct{}
+	once sync.Once
+}
+
+func (c *forwardedConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(func() { close(c.stop) })
+	return err
+}
+
+func (p *portForwardTransport) DialContext(ctx context.Context, pod corev1.Pod, port int) (net.Conn, error) {
+	roundTripper, upgrader, err := spdy.RoundTripperFor(p.config)
+	if err != nil {
+		return nil, err
+	}
+	url := p.clientset.CoreV1().RESTClient().Post().
+		Namespace(pod.Namespace).
+		Resource("pods").
+		Name(pod.Name).
+		SubResource("portforward").
+		URL()
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: roundTripper}, http.MethodPost, url)
+	stop := make(chan struct{})
+	ready := make(chan struct{})
+	forwarder, err := portforward.NewOnAddresses(dialer, []string{"127.0.0.1"}, []string{fmt.Sprintf("0:%d", port)}, stop, ready, io.Discard, io.Discard)
+	if err != nil {
+		return nil, err
+	}
+	forwardErr := make(chan error, 1)
+	go func() {
+		forwardErr <- forwarder.ForwardPorts()
+	}()
+	select {
+	case <-ready:
+	case err = <-forwardErr:
+		return nil, fmt.Errorf("cannot port-forward to pod %s: %w", pod.Name, err)
+	case <-ctx.Done():
+		close(stop)
+		return nil, ctx.Err()
+	}
+	ports, err := forwarder.GetPorts()
+	if err != nil {
+		close(stop)
+		return nil, err
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(int(ports[0].Local))))
+	if err != nil {
+		close(stop)
+		return nil, err
+	}
+	return &forwardedConn{Conn: conn, stop: stop}, nil
+}
+
+func (p *portForwardTransport) HTTPGet(ctx context.Context, pod corev1.Pod, scheme corev1.URIScheme, port int, path string, headers http.Header) (int, error) {
+	return dialHTTPGet(ctx, p.DialContext, pod, scheme, port, path, headers)
+}
+
+// CheckTCP cannot rely on the dial alone since the local listener always accepts. When nothing listens on the
+// pod port the port-forward closes the local connection right away, so it has to stay open for a moment.
+func (p *portForwardTransport) CheckTCP(ctx context.Context, pod corev1.Pod, port int) error {
+	conn, err := p.DialContext(ctx, pod, port)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return checkForwardedConn(ctx, conn)
+}
+
+func checkForwardedConn(ctx context.Context, conn net.Conn) error {
+	deadline := time.Now().Add(forwardSettleTime)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	if err := conn.SetReadDeadline(deadline); err != nil {
+		return err
+	}
+	_, err := conn.Read(make([]byte, 1))
+	var netErr net.Error
+	if err == nil || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return nil
+	}
+	if errors.Is(err, io.EOF) {
+		return ErrPortClosed
+	}
+	return err
+}
@@ -0,0 +1,70 @@
+package probe
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// dialTestListener connects to a local listener whose accepted connections are handed to accept
+func dialTestListener(t *testing.T, accept func(net.Conn)) net.Conn {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		if conn, err := listener.Accept(); err == nil {
+			accept(conn)
+		}
+	}()
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestCheckForwardedConn(t *testing.T) {
+	// the port-forward closes the local connection when the pod refuses it
+	conn := dialTestListener(t, func(c net.Conn) { c.Close() })
+	if err := checkForwardedConn(context.TODO(), conn); !errors.Is(err, ErrPortClosed) {
+		t.Fatalf("expected ErrPortClosed, got: %v", err)
+	}
+	conn = dialTestListener(t, func(c net.Conn) { t.Cleanup(func() { c.Close() }) })
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := checkForwardedConn(ctx, conn); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+}
+
+func TestPortForwardTransportForbidden(t *testing.T) {
+	var path string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+	config := &rest.Config{Host: srv.URL}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	transport := NewPortForwardTransport(config, clientset)
+	pod := newTestPod(8080, nil, nil)
+	if _, err = transport.DialContext(context.TODO(), *pod, 8080); err == nil {
+		t.Fatalf("expected error, got: %v", err)
+	}
+	if path != "/api/v1/namespaces/test-namespace/pods/test-pod/portforward" {
+		t.Fatalf("expected portforward subresource, got: %s", path)
+	}
+}
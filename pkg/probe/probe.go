@@ -0,0 +1,254 @@
+package probe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/vprashar2929/integration-test/pkg/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+)
+
+const defaultProbeTimeout = 1 * time.Second
+
+var (
+	ErrListingPod      = errors.New("error listing pods in namespace")
+	ErrNoPod           = errors.New("no running pods found in namespace")
+	ErrNoNamespace     = errors.New("no namespace provided")
+	ErrProbeFailed     = errors.New("probe failed")
+	ErrUnknownPort     = errors.New("probe port not found in container")
+	ErrInvalidInterval = errors.New("interval or timeout is invalid")
+)
+
+// Result is the outcome of executing a single probe definition
+type Result struct {
+	Pod       string
+	Container string
+	Probe     string
+	Type      string
+	Target    string
+	Status    string
+	Latency   time.Duration
+	Err       error
+}
+
+func (r Result) String() string {
+	return fmt.Sprintf("%s %s probe %s of container %s in pod %s", r.Probe, r.Type, r.Target, r.Container, r.Pod)
+}
+
+func getPods(namespace string, clientset kubernetes.Interface) ([]corev1.Pod, error) {
+	podList, err := clientset.CoreV1().Pods(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		logger.AppLog.LogError("error listing pods in namespace %s: %v\n", namespace, err)
+		return nil, ErrListingPod
+	}
+	var pods []corev1.Pod
+	for _, pod := range podList.Items {
+		if pod.Status.Phase == corev1.PodRunning {
+			pods = append(pods, pod)
+		}
+	}
+	if len(pods) == 0 {
+		return nil, ErrNoPod
+	}
+	return pods, nil
+}
+
+func storePodsByNamespace(namespaces []string, clientset kubernetes.Interface) (map[string][]corev1.Pod, error) {
+	if len(namespaces) == 0 {
+		return nil, ErrNoNamespace
+	}
+	podsByNamespace := make(map[string][]corev1.Pod)
+	for _, namespace := range namespaces {
+		if namespace == "" {
+			logger.AppLog.LogError("Invalid namespace provided.")
+			continue
+		}
+		pods, err := getPods(namespace, clientset)
+		if err != nil {
+			if errors.Is(err, ErrNoPod) {
+				continue
+			}
+			return nil, err
+		}
+		podsByNamespace[namespace] = pods
+	}
+	if len(podsByNamespace) == 0 {
+		return nil, ErrNoPod
+	}
+	return podsByNamespace, nil
+}
+
+// resolvePort turns a numeric or named probe port into a container port number
+func resolvePort(port intstr.IntOrString, container corev1.Container) (int, error) {
+	if port.Type == intstr.Int {
+		return port.IntValue(), nil
+	}
+	for _, containerPort := range container.Ports {
+		if containerPort.Name == port.StrVal {
+			return int(containerPort.ContainerPort), nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %s in container %s", ErrUnknownPort, port.StrVal, container.Name)
+}
+
+func checkGRPC(ctx context.Context, transport Transport, pod corev1.Pod, port int, service string) (string, error) {
+	conn, err := grpc.DialContext(ctx, pod.Name,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return transport.DialContext(ctx, pod, port)
+		}),
+	)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	response, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+	if err != nil {
+		return "", err
+	}
+	status := response.GetStatus().String()
+	if response.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		return status, fmt.Errorf("%w: grpc health status %s", ErrProbeFailed, status)
+	}
+	return status, nil
+}
+
+// runProbe executes the probe definition the way the kubelet would, bounded by the probe timeout
+func runProbe(transport Transport, pod corev1.Pod, container corev1.Container, kind string, probe *corev1.Probe) Result {
+	result := Result{Pod: pod.Name, Container: container.Name, Probe: kind}
+	timeout := defaultProbeTimeout
+	if probe.TimeoutSeconds > 0 {
+		timeout = time.Duration(probe.TimeoutSeconds) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	start := time.Now()
+	switch {
+	case probe.HTTPGet != nil:
+		result.Type = "http"
+		port, err := resolvePort(probe.HTTPGet.Port, container)
+		if err != nil {
+			result.Err = err
+			return result
+		}
+		result.Target = fmt.Sprintf(":%d%s", port, probe.HTTPGet.Path)
+		headers := http.Header{}
+		for _, header := range probe.HTTPGet.HTTPHeaders {
+			headers.Add(header.Name, header.Value)
+		}
+		scheme := probe.HTTPGet.Scheme
+		if scheme == "" {
+			scheme = corev1.URISchemeHTTP
+		}
+		code, err := transport.HTTPGet(ctx, pod, scheme, port, probe.HTTPGet.Path, headers)
+		result.Status = fmt.Sprint(code)
+		if err == nil && (code < http.StatusOK || code >= http.StatusBadRequest) {
+			err = fmt.Errorf("%w: http status %d", ErrProbeFailed, code)
+		}
+		result.Err = err
+	case probe.TCPSocket != nil:
+		result.Type = "tcp"
+		port, err := resolvePort(probe.TCPSocket.Port, container)
+		if err != nil {
+			result.Err = err
+			return result
+		}
+		result.Target = fmt.Sprintf(":%d", port)
+		result.Err = transport.CheckTCP(ctx, pod, port)
+		if result.Err == nil {
+			result.Status = "open"
+		}
+	case probe.GRPC != nil:
+		result.Type = "grpc"
+		service := ""
+		if probe.GRPC.Service != nil {
+			service = *probe.GRPC.Service
+		}
+		result.Target = fmt.Sprintf(":%d %s", probe.GRPC.Port, service)
+		result.Status, result.Err = checkGRPC(ctx, transport, pod, int(probe.GRPC.Port), service)
+	default:
+		// exec probes run inside the container and have nothing to reach from outside
+		return result
+	}
+	result.Latency = time.Since(start)
+	return result
+}
+
+// checkPodProbes runs the readiness and liveness probes of every container of the pod
+func checkPodProbes(transport Transport, pod corev1.Pod) error {
+	for _, container := range pod.Spec.Containers {
+		probes := []struct {
+			kind  string
+			probe *corev1.Probe
+		}{{"readiness", container.ReadinessProbe}, {"liveness", container.LivenessProbe}}
+		for _, p := range probes {
+			if p.probe == nil {
+				continue
+			}
+			result := runProbe(transport, pod, container, p.kind, p.probe)
+			if result.Type == "" {
+				continue
+			}
+			if errors.Is(result.Err, ErrDialUnsupported) {
+				logger.AppLog.LogWarning("%s skipped, the transport cannot reach it\n", result)
+				continue
+			}
+			if result.Err != nil {
+				return fmt.Errorf("%s: %w", result, result.Err)
+			}
+			logger.AppLog.LogInfo("%s returned %s in %v\n", result, result.Status, result.Latency)
+		}
+	}
+	return nil
+}
+
+func validateProbesByNamespace(namespaces []string, podsByNamespace map[string][]corev1.Pod, transport Transport, interval, timeout time.Duration) error {
+	var err error
+	if interval <= 0 || timeout <= 0 {
+		return ErrInvalidInterval
+	}
+	for _, namespace := range namespaces {
+		for _, pod := range podsByNamespace[namespace] {
+			deadline := time.Now().Add(timeout)
+			for time.Now().Before(deadline) {
+				if err = checkPodProbes(transport, pod); err == nil {
+					break
+				}
+				time.Sleep(interval)
+			}
+			if time.Now().After(deadline) {
+				return fmt.Errorf("timeout verifying probes of pod %s in namespace %s, error: %v", pod.Name, namespace, err)
+			}
+		}
+	}
+	return nil
+}
+
+func CheckProbes(namespaces []string, transport Transport, clientset kubernetes.Interface, interval, timeout time.Duration) error {
+	logger.AppLog.LogInfo("Begin probe verification")
+
+	podsByNamespace, err := storePodsByNamespace(namespaces, clientset)
+	if err != nil {
+		if errors.Is(err, ErrNoPod) {
+			logger.AppLog.LogWarning("No running pods found. Skipping validations.")
+			return nil
+		}
+		return err
+	}
+	if err = validateProbesByNamespace(namespaces, podsByNamespace, transport, interval, timeout); err != nil {
+		return err
+	}
+
+	logger.AppLog.LogInfo("End probe verification")
+	return nil
+}
@@ -0,0 +1,194 @@
+package probe
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vprashar2929/integration-test/pkg/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+)
+
+var (
+	testNS  = "test-namespace"
+	testPod = "test-pod"
+)
+
+// newTestServer starts the stand-in application, /healthz is healthy and everything else fails
+func newTestServer(t *testing.T) int {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/healthz" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+	return srv.Listener.Addr().(*net.TCPAddr).Port
+}
+
+func newTestPod(port int, readiness, liveness *corev1.Probe) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: testPod, Namespace: testNS},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name:           "app",
+				Ports:          []corev1.ContainerPort{{Name: "http", ContainerPort: int32(port)}},
+				ReadinessProbe: readiness,
+				LivenessProbe:  liveness,
+			}},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning, PodIP: "127.0.0.1"},
+	}
+}
+
+func newHTTPProbe(port intstr.IntOrString, path string) *corev1.Probe {
+	return &corev1.Probe{ProbeHandler: corev1.ProbeHandler{HTTPGet: &corev1.HTTPGetAction{Port: port, Path: path}}}
+}
+
+func TestRunProbeHTTP(t *testing.T) {
+	port := newTestServer(t)
+	pod := newTestPod(port, nil, nil)
+	result := runProbe(NewDirectTransport(), *pod, pod.Spec.Containers[0], "readiness", newHTTPProbe(intstr.FromString("http"), "/healthz"))
+	if result.Err != nil || result.Status != "200" {
+		t.Fatalf("expected status 200, got: %s %v", result.Status, result.Err)
+	}
+	result = runProbe(NewDirectTransport(), *pod, pod.Spec.Containers[0], "liveness", newHTTPProbe(intstr.FromInt(port), "/broken"))
+	if !errors.Is(result.Err, ErrProbeFailed) {
+		t.Fatalf("expected ErrProbeFailed, got: %v", result.Err)
+	}
+	result = runProbe(NewDirectTransport(), *pod, pod.Spec.Containers[0], "liveness", newHTTPProbe(intstr.FromString("metrics"), "/healthz"))
+	if !errors.Is(result.Err, ErrUnknownPort) {
+		t.Fatalf("expected ErrUnknownPort, got: %v", result.Err)
+	}
+}
+
+func TestRunProbeTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	pod := newTestPod(port, nil, nil)
+	probe := &corev1.Probe{ProbeHandler: corev1.ProbeHandler{TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt(port)}}}
+	if result := runProbe(NewDirectTransport(), *pod, pod.Spec.Containers[0], "liveness", probe); result.Err != nil {
+		t.Fatalf("expected nil, got: %v", result.Err)
+	}
+	listener.Close()
+	if result := runProbe(NewDirectTransport(), *pod, pod.Spec.Containers[0], "liveness", probe); result.Err == nil {
+		t.Fatalf("expected error, got: %v", result.Err)
+	}
+}
+
+func TestRunProbeGRPC(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("app", healthpb.HealthCheckResponse_NOT_SERVING)
+	srv := grpc.NewServer()
+	healthpb.RegisterHealthServer(srv, healthServer)
+	go srv.Serve(listener)
+	defer srv.Stop()
+	port := listener.Addr().(*net.TCPAddr).Port
+	pod := newTestPod(port, nil, nil)
+	probe := &corev1.Probe{ProbeHandler: corev1.ProbeHandler{GRPC: &corev1.GRPCAction{Port: int32(port)}}}
+	if result := runProbe(NewDirectTransport(), *pod, pod.Spec.Containers[0], "readiness", probe); result.Err != nil || result.Status != "SERVING" {
+		t.Fatalf("expected SERVING, got: %s %v", result.Status, result.Err)
+	}
+	service := "app"
+	probe.GRPC.Service = &service
+	if result := runProbe(NewDirectTransport(), *pod, pod.Spec.Containers[0], "readiness", probe); !errors.Is(result.Err, ErrProbeFailed) {
+		t.Fatalf("expected ErrProbeFailed, got: %v", result.Err)
+	}
+}
+
+func TestProxyTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/v1/namespaces/test-namespace/pods/test-pod:8080/proxy/healthz":
+			w.WriteHeader(http.StatusOK)
+		case strings.HasPrefix(r.URL.Path, "/api/v1/namespaces/test-namespace/pods/test-pod:8080/proxy"):
+			w.WriteHeader(http.StatusNotFound)
+		case strings.HasPrefix(r.URL.Path, "/api/v1/namespaces/test-namespace/pods/test-pod:7070/proxy"):
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"kind":"Status","apiVersion":"v1","status":"Failure","message":"pods \"test-pod\" is forbidden","reason":"Forbidden","code":403}`))
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"kind":"Status","apiVersion":"v1","status":"Failure","message":"error trying to reach service: dial tcp 10.0.0.1:9090: connect: connection refused","code":503}`))
+		}
+	}))
+	defer srv.Close()
+	clientset, err := kubernetes.NewForConfig(&rest.Config{Host: srv.URL})
+	if err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	transport := NewProxyTransport(clientset)
+	pod := newTestPod(8080, nil, nil)
+	if code, err := transport.HTTPGet(context.TODO(), *pod, corev1.URISchemeHTTP, 8080, "/healthz", nil); err != nil || code != http.StatusOK {
+		t.Fatalf("expected status 200, got: %d %v", code, err)
+	}
+	if err := transport.CheckTCP(context.TODO(), *pod, 8080); err != ErrDialUnsupported {
+		t.Fatalf("expected ErrDialUnsupported, got: %v", err)
+	}
+	if code, err := transport.HTTPGet(context.TODO(), *pod, corev1.URISchemeHTTP, 8080, "/missing", nil); err != nil || code != http.StatusNotFound {
+		t.Fatalf("expected the status of the pod, got: %d %v", code, err)
+	}
+	if _, err := transport.HTTPGet(context.TODO(), *pod, corev1.URISchemeHTTP, 9090, "/healthz", nil); err == nil || !strings.Contains(err.Error(), "error trying to reach") {
+		t.Fatalf("expected the proxy error, got: %v", err)
+	}
+	if _, err := transport.HTTPGet(context.TODO(), *pod, corev1.URISchemeHTTP, 7070, "/healthz", nil); !apierrors.IsForbidden(err) {
+		t.Fatalf("expected forbidden, got: %v", err)
+	}
+	if _, err := transport.DialContext(context.TODO(), *pod, 8080); err != ErrDialUnsupported {
+		t.Fatalf("expected ErrDialUnsupported, got: %v", err)
+	}
+}
+
+func TestCheckProbes(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	port := newTestServer(t)
+	clientset := fake.NewSimpleClientset(newTestPod(port, newHTTPProbe(intstr.FromInt(port), "/healthz"), nil))
+	interval := 1 * time.Second
+	timeout := 5 * time.Second
+	if err := CheckProbes([]string{testNS}, NewDirectTransport(), clientset, interval, timeout); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+}
+
+func TestCheckProbesFailing(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	port := newTestServer(t)
+	clientset := fake.NewSimpleClientset(newTestPod(port, nil, newHTTPProbe(intstr.FromInt(port), "/broken")))
+	interval := 1 * time.Second
+	timeout := 2 * time.Second
+	if err := CheckProbes([]string{testNS}, NewDirectTransport(), clientset, interval, timeout); err == nil {
+		t.Fatalf("expected error, got: %v", err)
+	}
+}
+
+func TestCheckProbesNoNamespace(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	interval := 1 * time.Second
+	timeout := 5 * time.Second
+	if err := CheckProbes([]string{}, NewDirectTransport(), fake.NewSimpleClientset(), interval, timeout); err != ErrNoNamespace {
+		t.Fatalf("expected ErrNoNamespace, got: %v", err)
+	}
+}
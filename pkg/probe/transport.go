@@ -0,0 +1,159 @@
+package probe
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/kubernetes"
+)
+
+var ErrDialUnsupported = errors.New("transport cannot open connections to pods")
+
+// Transport carries probe requests to a port of a pod
+type Transport interface {
+	// HTTPGet sends a GET request to the pod and returns the response status code
+	HTTPGet(ctx context.Context, pod corev1.Pod, scheme corev1.URIScheme, port int, path string, headers http.Header) (int, error)
+	// CheckTCP returns nil when the port of the pod accepts connections
+	CheckTCP(ctx context.Context, pod corev1.Pod, port int) error
+	// DialContext opens a connection to the port of the pod
+	DialContext(ctx context.Context, pod corev1.Pod, port int) (net.Conn, error)
+}
+
+// proxyTransport reaches pods through the pods/proxy subresource of the API server. It cannot open raw
+// connections, so gRPC probes are not supported.
+type proxyTransport struct {
+	clientset kubernetes.Interface
+}
+
+// NewProxyTransport returns a transport going through the API server pod proxy
+func NewProxyTransport(clientset kubernetes.Interface) Transport {
+	return &proxyTransport{clientset: clientset}
+}
+
+func (p *proxyTransport) get(ctx context.Context, pod corev1.Pod, scheme corev1.URIScheme, port int, path string, headers http.Header) (int, error) {
+	name := pod.Name + ":" + strconv.Itoa(port)
+	if scheme == corev1.URISchemeHTTPS {
+		name = "https:" + name
+	}
+	request := p.clientset.CoreV1().RESTClient().Get().
+		Namespace(pod.Namespace).
+		Resource("pods").
+		Name(name).
+		SubResource("proxy").
+		Suffix(path)
+	for key, values := range headers {
+		request = request.SetHeader(key, values...)
+	}
+	var code int
+	err := request.Do(ctx).StatusCode(&code).Error()
+	return code, err
+}
+
+// isProxyError reports whether the API server itself refused the request or could not reach the pod, as
+// opposed to relaying a response of the pod
+func isProxyError(err error) bool {
+	if apierrors.IsForbidden(err) || apierrors.IsUnauthorized(err) {
+		return true
+	}
+	var statusErr *apierrors.StatusError
+	if errors.As(err, &statusErr) {
+		message := statusErr.ErrStatus.Message
+		return strings.Contains(message, "error trying to reach") || strings.Contains(message, "dial tcp")
+	}
+	return false
+}
+
+func (p *proxyTransport) HTTPGet(ctx context.Context, pod corev1.Pod, scheme corev1.URIScheme, port int, path string, headers http.Header) (int, error) {
+	code, err := p.get(ctx, pod, scheme, port, path, headers)
+	if err != nil && isProxyError(err) {
+		return 0, fmt.Errorf("api server proxy to pod %s failed: %w", pod.Name, err)
+	}
+	// the API server turns non 2xx responses of the pod into errors, the status code is what the probe needs
+	if code != 0 {
+		return code, nil
+	}
+	return code, err
+}
+
+// CheckTCP is not supported, the pod proxy only speaks HTTP and reports a healthy non HTTP listener such as a
+// database as unreachable
+func (p *proxyTransport) CheckTCP(ctx context.Context, pod corev1.Pod, port int) error {
+	return ErrDialUnsupported
+}
+
+func (p *proxyTransport) DialContext(ctx context.Context, pod corev1.Pod, port int) (net.Conn, error) {
+	return nil, ErrDialUnsupported
+}
+
+// dialer opens connections to a port of a pod
+type dialer func(ctx context.Context, pod corev1.Pod, port int) (net.Conn, error)
+
+// dialHTTPGet sends the request over a connection opened by dial, the host of the url is never resolved
+func dialHTTPGet(ctx context.Context, dial dialer, pod corev1.Pod, scheme corev1.URIScheme, port int, path string, headers http.Header) (int, error) {
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return dial(ctx, pod, port)
+			},
+			// the kubelet does not verify the certificates of https probes either
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: true}, // #nosec G402
+			DisableKeepAlives: true,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	url := fmt.Sprintf("%s://%s:%d%s", strings.ToLower(string(scheme)), pod.Name, port, path)
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	for key, values := range headers {
+		request.Header[key] = values
+	}
+	if host := headers.Get("Host"); host != "" {
+		request.Host = host
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	return response.StatusCode, nil
+}
+
+// directTransport connects to the pod IP, which only works from inside the cluster network
+type directTransport struct{}
+
+// NewDirectTransport returns a transport dialing pod IPs directly
+func NewDirectTransport() Transport {
+	return directTransport{}
+}
+
+func (directTransport) DialContext(ctx context.Context, pod corev1.Pod, port int) (net.Conn, error) {
+	if pod.Status.PodIP == "" {
+		return nil, fmt.Errorf("pod %s has no IP", pod.Name)
+	}
+	var d net.Dialer
+	return d.DialContext(ctx, "tcp", net.JoinHostPort(pod.Status.PodIP, strconv.Itoa(port)))
+}
+
+func (t directTransport) HTTPGet(ctx context.Context, pod corev1.Pod, scheme corev1.URIScheme, port int, path string, headers http.Header) (int, error) {
+	return dialHTTPGet(ctx, t.DialContext, pod, scheme, port, path, headers)
+}
+
+func (t directTransport) CheckTCP(ctx context.Context, pod corev1.Pod, port int) error {
+	conn, err := t.DialContext(ctx, pod, port)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
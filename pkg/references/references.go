@@ -0,0 +1,235 @@
+package references
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/vprashar2929/integration-test/pkg/logger"
+	"github.com/vprashar2929/integration-test/pkg/workload"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	kindConfigMap = "ConfigMap"
+	kindSecret    = "Secret"
+)
+
+var (
+	ErrListingWorkload  = workload.ErrListingWorkload
+	ErrNoWorkload       = workload.ErrNoWorkload
+	ErrNoNamespace      = workload.ErrNoNamespace
+	ErrMissingReference = errors.New("pod template references missing configmaps or secrets")
+	ErrInvalidInterval  = errors.New("interval or timeout is invalid")
+)
+
+// reference is a configmap or secret, or a single key of one, used by a pod template
+type reference struct {
+	Kind     string
+	Name     string
+	Key      string
+	Optional bool
+	Source   string
+}
+
+func (r reference) String() string {
+	name := r.Kind + " " + r.Name
+	if r.Key != "" {
+		name += " key " + r.Key
+	}
+	return name + " (" + r.Source + ")"
+}
+
+func isOptional(optional *bool) bool {
+	return optional != nil && *optional
+}
+
+func getContainerReferences(container corev1.Container) []reference {
+	var refs []reference
+	source := "container " + container.Name
+	for _, env := range container.Env {
+		if env.ValueFrom == nil {
+			continue
+		}
+		if ref := env.ValueFrom.ConfigMapKeyRef; ref != nil {
+			refs = append(refs, reference{Kind: kindConfigMap, Name: ref.Name, Key: ref.Key, Optional: isOptional(ref.Optional), Source: source + " env " + env.Name})
+		}
+		if ref := env.ValueFrom.SecretKeyRef; ref != nil {
+			refs = append(refs, reference{Kind: kindSecret, Name: ref.Name, Key: ref.Key, Optional: isOptional(ref.Optional), Source: source + " env " + env.Name})
+		}
+	}
+	for _, envFrom := range container.EnvFrom {
+		if ref := envFrom.ConfigMapRef; ref != nil {
+			refs = append(refs, reference{Kind: kindConfigMap, Name: ref.Name, Optional: isOptional(ref.Optional), Source: source + " envFrom"})
+		}
+		if ref := envFrom.SecretRef; ref != nil {
+			refs = append(refs, reference{Kind: kindSecret, Name: ref.Name, Optional: isOptional(ref.Optional), Source: source + " envFrom"})
+		}
+	}
+	return refs
+}
+
+func getKeyReferences(kind, name string, items []corev1.KeyToPath, optional bool, source string) []reference {
+	refs := []reference{{Kind: kind, Name: name, Optional: optional, Source: source}}
+	for _, item := range items {
+		refs = append(refs, reference{Kind: kind, Name: name, Key: item.Key, Optional: optional, Source: source})
+	}
+	return refs
+}
+
+func getVolumeReferences(volume corev1.Volume) []reference {
+	var refs []reference
+	source := "volume " + volume.Name
+	if cm := volume.ConfigMap; cm != nil {
+		refs = append(refs, getKeyReferences(kindConfigMap, cm.Name, cm.Items, isOptional(cm.Optional), source)...)
+	}
+	if secret := volume.Secret; secret != nil {
+		refs = append(refs, getKeyReferences(kindSecret, secret.SecretName, secret.Items, isOptional(secret.Optional), source)...)
+	}
+	if projected := volume.Projected; projected != nil {
+		for _, projection := range projected.Sources {
+			if cm := projection.ConfigMap; cm != nil {
+				refs = append(refs, getKeyReferences(kindConfigMap, cm.Name, cm.Items, isOptional(cm.Optional), source)...)
+			}
+			if secret := projection.Secret; secret != nil {
+				refs = append(refs, getKeyReferences(kindSecret, secret.Name, secret.Items, isOptional(secret.Optional), source)...)
+			}
+		}
+	}
+	return refs
+}
+
+// getPodSpecReferences returns every configmap and secret the pod spec needs to start
+func getPodSpecReferences(spec corev1.PodSpec) []reference {
+	var refs []reference
+	for _, container := range append(append([]corev1.Container{}, spec.InitContainers...), spec.Containers...) {
+		refs = append(refs, getContainerReferences(container)...)
+	}
+	for _, volume := range spec.Volumes {
+		refs = append(refs, getVolumeReferences(volume)...)
+	}
+	for _, pullSecret := range spec.ImagePullSecrets {
+		refs = append(refs, reference{Kind: kindSecret, Name: pullSecret.Name, Source: "imagePullSecrets"})
+	}
+	return refs
+}
+
+// getKeys returns the keys of a configmap or secret, or nil when it does not exist
+func getKeys(namespace, kind, name string, clientset kubernetes.Interface) (map[string]bool, error) {
+	keys := make(map[string]bool)
+	switch kind {
+	case kindConfigMap:
+		cm, err := clientset.CoreV1().ConfigMaps(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		for key := range cm.Data {
+			keys[key] = true
+		}
+		for key := range cm.BinaryData {
+			keys[key] = true
+		}
+	case kindSecret:
+		secret, err := clientset.CoreV1().Secrets(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		for key := range secret.Data {
+			keys[key] = true
+		}
+		for key := range secret.StringData {
+			keys[key] = true
+		}
+	}
+	return keys, nil
+}
+
+// getMissingReferences looks up every object the pod template needs, each one only once, and returns the
+// required references that do not resolve
+func getMissingReferences(namespace string, w workload.Workload, clientset kubernetes.Interface) ([]string, error) {
+	keysByObject := make(map[string]map[string]bool)
+	var missing []string
+	for _, ref := range getPodSpecReferences(w.Spec) {
+		object := ref.Kind + "/" + ref.Name
+		keys, ok := keysByObject[object]
+		if !ok {
+			var err error
+			if keys, err = getKeys(namespace, ref.Kind, ref.Name, clientset); err != nil {
+				return nil, err
+			}
+			keysByObject[object] = keys
+		}
+		if ref.Optional {
+			continue
+		}
+		if keys == nil || (ref.Key != "" && !keys[ref.Key]) {
+			missing = append(missing, ref.String())
+		}
+	}
+	return missing, nil
+}
+
+// validateReferencesByNamespace checks every workload once. A missing configmap or secret will not show up while
+// waiting, so only errors reading them are retried and every missing reference is reported together.
+func validateReferencesByNamespace(namespaces []string, workloadsByNamespace map[string][]workload.Workload, clientset kubernetes.Interface, interval, timeout time.Duration) error {
+	var err error
+	if interval <= 0 || timeout <= 0 {
+		return ErrInvalidInterval
+	}
+	var failed []string
+	for _, namespace := range namespaces {
+		for _, w := range workloadsByNamespace[namespace] {
+			var missing []string
+			deadline := time.Now().Add(timeout)
+			for time.Now().Before(deadline) {
+				if missing, err = getMissingReferences(namespace, w, clientset); err == nil {
+					break
+				}
+				time.Sleep(interval)
+			}
+			if time.Now().After(deadline) {
+				return fmt.Errorf("timeout checking references of %s %s in namespace %s, error: %v", w.Kind, w.Name, namespace, err)
+			}
+			if len(missing) > 0 {
+				logger.AppLog.LogError("%s %s in namespace %s references missing %s\n", w.Kind, w.Name, namespace, strings.Join(missing, ", "))
+				failed = append(failed, fmt.Sprintf("%s %s in namespace %s: %s", w.Kind, w.Name, namespace, strings.Join(missing, ", ")))
+				continue
+			}
+			logger.AppLog.LogInfo("%s %s references resolve in namespace %s\n", w.Kind, w.Name, namespace)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%w: %s", ErrMissingReference, strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+func CheckReferences(namespaces []string, clientset kubernetes.Interface, interval, timeout time.Duration) error {
+	logger.AppLog.LogInfo("Begin ConfigMap and Secret reference validation")
+
+	workloadsByNamespace, err := workload.ListByNamespace(namespaces, clientset)
+	if err != nil {
+		if errors.Is(err, ErrNoWorkload) {
+			logger.AppLog.LogWarning("No workloads found. Skipping validations.")
+			return nil
+		}
+		return err
+	}
+	if err = validateReferencesByNamespace(namespaces, workloadsByNamespace, clientset, interval, timeout); err != nil {
+		return err
+	}
+
+	logger.AppLog.LogInfo("End ConfigMap and Secret reference validation")
+	return nil
+}
@@ -0,0 +1,175 @@
+package references
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vprashar2929/integration-test/pkg/logger"
+	"github.com/vprashar2929/integration-test/pkg/workload"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var (
+	testNS         = "test-namespace"
+	testDep        = "test-deployment"
+	testDeployment = appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: testDep, Namespace: testNS},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{Spec: newTestPodSpec()},
+		},
+	}
+)
+
+func newTestPodSpec() corev1.PodSpec {
+	optional := true
+	return corev1.PodSpec{
+		Containers: []corev1.Container{
+			{
+				Name: "app",
+				Env: []corev1.EnvVar{
+					{Name: "LEVEL", ValueFrom: &corev1.EnvVarSource{ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "app-config"}, Key: "level",
+					}}},
+					{Name: "TOKEN", ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "app-secret"}, Key: "token",
+					}}},
+				},
+				EnvFrom: []corev1.EnvFromSource{
+					{ConfigMapRef: &corev1.ConfigMapEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "extra"}, Optional: &optional}},
+				},
+			},
+		},
+		Volumes: []corev1.Volume{
+			{Name: "certs", VolumeSource: corev1.VolumeSource{Projected: &corev1.ProjectedVolumeSource{
+				Sources: []corev1.VolumeProjection{
+					{Secret: &corev1.SecretProjection{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "app-tls"},
+						Items:                []corev1.KeyToPath{{Key: "tls.crt", Path: "tls.crt"}},
+					}},
+				},
+			}}},
+		},
+		ImagePullSecrets: []corev1.LocalObjectReference{{Name: "registry"}},
+	}
+}
+
+func newTestConfigMap(name string, data map[string]string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNS}, Data: data}
+}
+
+func newTestSecret(name string, keys ...string) *corev1.Secret {
+	data := make(map[string][]byte)
+	for _, key := range keys {
+		data[key] = []byte("value")
+	}
+	return &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNS}, Data: data}
+}
+
+func TestGetPodSpecReferences(t *testing.T) {
+	refs := getPodSpecReferences(newTestPodSpec())
+	// env configmap key, env secret key, optional envFrom, projected secret and its key, pull secret
+	if len(refs) != 6 {
+		t.Fatalf("expected 6 references, got: %v", refs)
+	}
+}
+
+func TestGetMissingReferences(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	clientset := fake.NewSimpleClientset(
+		newTestConfigMap("app-config", map[string]string{"level": "info"}),
+		newTestSecret("app-secret", "token"),
+		newTestSecret("app-tls", "tls.crt", "tls.key"),
+		newTestSecret("registry", ".dockerconfigjson"),
+	)
+	w := workload.Workload{Kind: workload.KindDeployment, Name: testDep, Spec: newTestPodSpec()}
+	missing, err := getMissingReferences(testNS, w, clientset)
+	if err != nil || len(missing) != 0 {
+		t.Fatalf("expected no missing references, got: %v, %v", missing, err)
+	}
+}
+
+func TestGetMissingReferencesMissing(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	clientset := fake.NewSimpleClientset(
+		newTestConfigMap("app-config", map[string]string{"verbosity": "info"}),
+		newTestSecret("app-tls", "tls.crt"),
+		newTestSecret("registry", ".dockerconfigjson"),
+	)
+	w := workload.Workload{Kind: workload.KindDeployment, Name: testDep, Spec: newTestPodSpec()}
+	missing, err := getMissingReferences(testNS, w, clientset)
+	if err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	joined := strings.Join(missing, ", ")
+	for _, expected := range []string{"ConfigMap app-config key level", "Secret app-secret"} {
+		if !strings.Contains(joined, expected) {
+			t.Errorf("expected %q in missing references, got: %v", expected, joined)
+		}
+	}
+	if strings.Contains(joined, "extra") {
+		t.Errorf("expected optional configmap to be ignored, got: %v", joined)
+	}
+}
+
+func TestCheckReferences(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	clientset := fake.NewSimpleClientset(
+		&testDeployment,
+		newTestConfigMap("app-config", map[string]string{"level": "info"}),
+		newTestSecret("app-secret", "token"),
+		newTestSecret("app-tls", "tls.crt"),
+		newTestSecret("registry", ".dockerconfigjson"),
+	)
+	interval := 1 * time.Second
+	timeout := 5 * time.Second
+	if err := CheckReferences([]string{testNS}, clientset, interval, timeout); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+}
+
+func TestCheckReferencesMissing(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	clientset := fake.NewSimpleClientset(&testDeployment)
+	interval := 1 * time.Second
+	timeout := 2 * time.Second
+	if err := CheckReferences([]string{testNS}, clientset, interval, timeout); err == nil {
+		t.Fatalf("expected error, got: %v", err)
+	}
+}
+
+func TestValidateReferencesByNamespaceCombinesMissing(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	spec := corev1.PodSpec{Containers: []corev1.Container{{
+		Name:    "app",
+		EnvFrom: []corev1.EnvFromSource{{ConfigMapRef: &corev1.ConfigMapEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "shared-config"}}}},
+	}}}
+	workloadsByNamespace := map[string][]workload.Workload{testNS: {
+		{Kind: "Deployment", Name: "api", Spec: spec},
+		{Kind: workload.KindStatefulSet, Name: "db", Spec: spec},
+	}}
+	start := time.Now()
+	err := validateReferencesByNamespace([]string{testNS}, workloadsByNamespace, fake.NewSimpleClientset(), time.Second, time.Minute)
+	if !errors.Is(err, ErrMissingReference) {
+		t.Fatalf("expected ErrMissingReference, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), "Deployment api") || !strings.Contains(err.Error(), "StatefulSet db") {
+		t.Errorf("expected both workloads in error, got: %v", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Errorf("expected missing references to fail without waiting for the timeout, took %v", time.Since(start))
+	}
+}
+
+func TestCheckReferencesNoNamespace(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	interval := 1 * time.Second
+	timeout := 5 * time.Second
+	if err := CheckReferences([]string{}, fake.NewSimpleClientset(), interval, timeout); err != ErrNoNamespace {
+		t.Fatalf("expected ErrNoNamespace, got: %v", err)
+	}
+}
@@ -11,6 +11,7 @@ import (
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 
@@ -40,8 +41,63 @@ var (
 	ErrReplicaSetNotHealthy = errors.New("replicaset not in healthy state")
 	ErrInvalidInterval      = errors.New("invalid interval or timeout")
 	ErrReplicaSetFailed     = errors.New("replicaset validation failed")
+	ErrGettingOwner         = errors.New("error getting owner deployment of replicaset")
 )
 
+const revisionAnnotation = "deployment.kubernetes.io/revision"
+
+func desiredReplicas(replicaset *appsv1.ReplicaSet) int32 {
+	if replicaset.Spec.Replicas == nil {
+		return 1
+	}
+	return *replicaset.Spec.Replicas
+}
+
+// isCurrentRevision reports whether the replicaset is the one its owning deployment currently rolls out. A
+// replicaset whose deployment was deleted is waiting for garbage collection and is not current.
+func isCurrentRevision(namespace string, replicaset appsv1.ReplicaSet, owner *metav1.OwnerReference, clientset kubernetes.Interface) (bool, error) {
+	deployment, err := clientset.AppsV1().Deployments(namespace).Get(context.TODO(), owner.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		logger.AppLog.LogError("cannot get deployment %s owning replicaset %s, err: %v\n", owner.Name, replicaset.Name, err)
+		return false, ErrGettingOwner
+	}
+	if deployment.UID != owner.UID {
+		return false, nil
+	}
+	revision := deployment.Annotations[revisionAnnotation]
+	return revision != "" && replicaset.Annotations[revisionAnnotation] == revision, nil
+}
+
+// filterReplicaSets drops replicasets managed by a deployment, as those are validated through the deployment.
+// When includeCurrentRevision is set the replicaset of each deployment's current revision is kept.
+func filterReplicaSets(namespace string, replicasets []appsv1.ReplicaSet, includeCurrentRevision bool, clientset kubernetes.Interface) ([]appsv1.ReplicaSet, error) {
+	var filtered []appsv1.ReplicaSet
+	for _, replicaset := range replicasets {
+		owner := metav1.GetControllerOf(&replicaset)
+		if owner == nil {
+			filtered = append(filtered, replicaset)
+			continue
+		}
+		if owner.Kind != "Deployment" || !includeCurrentRevision {
+			logger.AppLog.LogDebug("skipping replicaset %s owned by %s %s\n", replicaset.Name, owner.Kind, owner.Name)
+			continue
+		}
+		current, err := isCurrentRevision(namespace, replicaset, owner, clientset)
+		if err != nil {
+			return nil, err
+		}
+		if !current {
+			logger.AppLog.LogDebug("skipping replicaset %s of an old revision of deployment %s\n", replicaset.Name, owner.Name)
+			continue
+		}
+		filtered = append(filtered, replicaset)
+	}
+	return filtered, nil
+}
+
 func getReplicaSet(namespace string, clientset kubernetes.Interface) (*appsv1.ReplicaSetList, error) {
 	replicaset, err := clientset.AppsV1().ReplicaSets(namespace).List(context.TODO(), metav1.ListOptions{})
 	if err != nil {
@@ -52,7 +108,7 @@ func getReplicaSet(namespace string, clientset kubernetes.Interface) (*appsv1.Re
 	}
 	return replicaset, nil
 }
-func storeReplicaSetsByNamespace(namespaces []string, clientset kubernetes.Interface) (map[string][]appsv1.ReplicaSet, error) {
+func storeReplicaSetsByNamespace(namespaces []string, includeCurrentRevision bool, clientset kubernetes.Interface) (map[string][]appsv1.ReplicaSet, error) {
 	if len(namespaces) == 0 {
 		return nil, ErrNamespaceEmpty
 	}
@@ -66,7 +122,15 @@ func storeReplicaSetsByNamespace(namespaces []string, clientset kubernetes.Inter
 		if err != nil {
 			return nil, err
 		}
-		replicasetsByNamespace[namespace] = replicaSetList.Items
+		replicaSets, err := filterReplicaSets(namespace, replicaSetList.Items, includeCurrentRevision, clientset)
+		if err != nil {
+			return nil, err
+		}
+		if len(replicaSets) == 0 {
+			logger.AppLog.LogDebug("no standalone replicasets in namespace %s\n", namespace)
+			continue
+		}
+		replicasetsByNamespace[namespace] = replicaSets
 	}
 	if len(replicasetsByNamespace) == 0 {
 		return nil, ErrNoReplicaSet
@@ -79,9 +143,11 @@ func checkReplicaSetsStatus(namespace string, replicaset appsv1.ReplicaSet, clie
 		if err != nil {
 			return err
 		}
-		if updatedReplicaSet.Status.Replicas == *replicaset.Spec.Replicas &&
-			updatedReplicaSet.Status.ObservedGeneration >= replicaset.Generation &&
-			updatedReplicaSet.Status.ReadyReplicas == replicaset.Status.Replicas {
+		replicas := desiredReplicas(updatedReplicaSet)
+		if updatedReplicaSet.Status.Replicas == replicas &&
+			updatedReplicaSet.Status.ObservedGeneration >= updatedReplicaSet.Generation &&
+			updatedReplicaSet.Status.ReadyReplicas == replicas &&
+			updatedReplicaSet.Status.AvailableReplicas == replicas {
 			logger.AppLog.LogInfo("replicaset %s is available in namespace %s\n", replicaset.Name, namespace)
 			return nil
 		}
@@ -113,6 +179,9 @@ func validateReplicaSetsByNamespace(namespaces []string, replicasetsByNamespace
 			if time.Now().After(deadline) {
 				return fmt.Errorf("timeout checking replicaset status for %s in namespace %s, error: %v", replicaset.Name, namespace, err)
 			}
+			if desiredReplicas(&replicaset) == 0 {
+				continue
+			}
 			// check pod status
 			deadline = time.Now().Add(timeout)
 			for time.Now().Before(deadline) {
@@ -128,9 +197,12 @@ func validateReplicaSetsByNamespace(namespaces []string, replicasetsByNamespace
 	}
 	return nil
 }
-func CheckReplicaSets(namespaces []string, clienset kubernetes.Interface, interval, timeout time.Duration) error {
+
+// CheckReplicaSets validates standalone replicasets. Replicasets owned by a deployment are skipped unless
+// includeCurrentRevision is set, in which case the replicaset of the deployment's current revision is validated too.
+func CheckReplicaSets(namespaces []string, includeCurrentRevision bool, clienset kubernetes.Interface, interval, timeout time.Duration) error {
 	logger.AppLog.LogInfo("Begin ReplicaSet validation")
-	replicaSetsByNamespace, err := storeReplicaSetsByNamespace(namespaces, clienset)
+	replicaSetsByNamespace, err := storeReplicaSetsByNamespace(namespaces, includeCurrentRevision, clienset)
 	if err != nil {
 		if errors.Is(err, ErrNoReplicaSet) {
 			logger.AppLog.LogWarning("No replicasets found. Skipping validations.")
@@ -1,6 +1,7 @@
 package replicaset
 
 import (
+	"errors"
 	"testing"
 	"time"
 
@@ -8,8 +9,11 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
 )
 
 var (
@@ -69,7 +73,7 @@ func TestGetReplicaSetNoReplicaSet(t *testing.T) {
 func TestStoreReplicaSetsByNamespace(t *testing.T) {
 	clientset := fake.NewSimpleClientset(&testReplicaSetList)
 	namespaces := []string{testNS}
-	replicasetsByNamespace, err := storeReplicaSetsByNamespace(namespaces, clientset)
+	replicasetsByNamespace, err := storeReplicaSetsByNamespace(namespaces, false, clientset)
 	if err != nil {
 		t.Fatalf("expected nil, got: %v", err)
 	}
@@ -82,7 +86,7 @@ func TestStoreReplicaSetsByNamespaceNoNamespace(t *testing.T) {
 	clientset := fake.NewSimpleClientset(&testReplicaSetList)
 	logger.NewLogger(logger.LevelInfo)
 	namespaces := []string{}
-	_, err := storeReplicaSetsByNamespace(namespaces, clientset)
+	_, err := storeReplicaSetsByNamespace(namespaces, false, clientset)
 	if err != ErrNamespaceEmpty {
 		t.Fatalf("expected ErrNamespaceEmpty, got: %v", err)
 	}
@@ -92,7 +96,7 @@ func TestStoreReplicaSetsByNamespaceNoReplicaSets(t *testing.T) {
 	clientset := fake.NewSimpleClientset()
 	logger.NewLogger(logger.LevelInfo)
 	namespaces := []string{testNS}
-	_, err := storeReplicaSetsByNamespace(namespaces, clientset)
+	_, err := storeReplicaSetsByNamespace(namespaces, false, clientset)
 	if err != ErrNoReplicaSet {
 		t.Fatalf("expected ErrNoReplicaSet, got: %v", err)
 	}
@@ -300,7 +304,7 @@ func TestCheckReplicaSets(t *testing.T) {
 	namespaces := []string{testNS}
 	interval := 1 * time.Second
 	timeout := 5 * time.Second
-	err := CheckReplicaSets(namespaces, clientset, interval, timeout)
+	err := CheckReplicaSets(namespaces, false, clientset, interval, timeout)
 	if err != nil {
 		t.Fatalf("expected nil, got: %v", err)
 	}
@@ -314,8 +318,106 @@ func TestCheckReplicaSetsNoNamespace(t *testing.T) {
 	namespaces := []string{}
 	interval := 1 * time.Second
 	timeout := 5 * time.Second
-	err := CheckReplicaSets(namespaces, clientset, interval, timeout)
+	err := CheckReplicaSets(namespaces, false, clientset, interval, timeout)
 	if err != ErrNamespaceEmpty {
 		t.Fatalf("expected ErrNamespaceEmpty, got: %v", err)
 	}
 }
+
+func TestFilterReplicaSets(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test-deployment",
+			Namespace:   testNS,
+			UID:         types.UID("test-deployment-uid"),
+			Annotations: map[string]string{revisionAnnotation: "2"},
+		},
+	}
+	ownedBy := func(name, revision string) appsv1.ReplicaSet {
+		return appsv1.ReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            name,
+				Namespace:       testNS,
+				Annotations:     map[string]string{revisionAnnotation: revision},
+				OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(deployment, appsv1.SchemeGroupVersion.WithKind("Deployment"))},
+			},
+		}
+	}
+	replicaSets := []appsv1.ReplicaSet{
+		testReplicaSetList.Items[0],
+		ownedBy("test-deployment-1", "1"),
+		ownedBy("test-deployment-2", "2"),
+	}
+	clientset := fake.NewSimpleClientset(deployment)
+
+	filtered, err := filterReplicaSets(testNS, replicaSets, false, clientset)
+	if err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	if len(filtered) != 1 || filtered[0].Name != testReplicaSet {
+		t.Errorf("expected only standalone replicaset, got: %v", filtered)
+	}
+
+	filtered, err = filterReplicaSets(testNS, replicaSets, true, clientset)
+	if err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	if len(filtered) != 2 || filtered[1].Name != "test-deployment-2" {
+		t.Errorf("expected standalone and current revision replicasets, got: %v", filtered)
+	}
+}
+
+func TestFilterReplicaSetsMissingOwner(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	isController := true
+	replicaSets := []appsv1.ReplicaSet{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "orphan-1",
+				Namespace: testNS,
+				OwnerReferences: []metav1.OwnerReference{
+					{Kind: "Deployment", Name: "missing", Controller: &isController},
+				},
+			},
+		},
+	}
+	clientset := fake.NewSimpleClientset()
+	filtered, err := filterReplicaSets(testNS, replicaSets, true, clientset)
+	if err != nil || len(filtered) != 0 {
+		t.Fatalf("expected the orphan to be skipped, got: %v %v", filtered, err)
+	}
+
+	clientset.PrependReactor("get", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("connection refused")
+	})
+	if _, err = filterReplicaSets(testNS, replicaSets, true, clientset); err != ErrGettingOwner {
+		t.Fatalf("expected ErrGettingOwner, got: %v", err)
+	}
+}
+
+func TestCheckReplicaSetsOnlyDeploymentOwned(t *testing.T) {
+	isController := true
+	ownedRS := appsv1.ReplicaSetList{
+		Items: []appsv1.ReplicaSet{
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-deployment-1",
+					Namespace: testNS,
+					OwnerReferences: []metav1.OwnerReference{
+						{Kind: "Deployment", Name: "test-deployment", Controller: &isController},
+					},
+				},
+			},
+		},
+	}
+	clientset := fake.NewSimpleClientset(&ownedRS)
+	retryer = &mockRetryer{err: ErrReplicaSetNotHealthy}
+	logger.NewLogger(logger.LevelInfo)
+	interval := 1 * time.Second
+	timeout := 5 * time.Second
+	err := CheckReplicaSets([]string{testNS}, false, clientset, interval, timeout)
+	if err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+}
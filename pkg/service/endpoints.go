@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+
+	"github.com/vprashar2929/integration-test/pkg/logger"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+)
+
+// backend is a single ready address behind a service along with the port numbers it serves, keyed by port name.
+// Pod is the name of the pod behind the address, empty for manually managed endpoints.
+type backend struct {
+	Address  string
+	Hostname string
+	Pod      string
+	Ports    map[string]int32
+}
+
+func podName(targetRef *corev1.ObjectReference) string {
+	if targetRef == nil || targetRef.Kind != "Pod" {
+		return ""
+	}
+	return targetRef.Name
+}
+
+// namedPort returns the number of the container port called name in the pod
+func namedPort(pod *corev1.Pod, name string) (int32, bool) {
+	for _, container := range pod.Spec.Containers {
+		for _, port := range container.Ports {
+			if port.Name == name {
+				return port.ContainerPort, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// portMatches reports whether an endpoint port number serves the service port. Named target ports resolve
+// to a container port per pod, so they are compared against the ports of the backing pod, when known.
+func portMatches(port corev1.ServicePort, number int32, pod *corev1.Pod) bool {
+	switch {
+	case port.TargetPort.Type == intstr.String && port.TargetPort.StrVal != "":
+		if pod == nil {
+			return true
+		}
+		resolved, ok := namedPort(pod, port.TargetPort.StrVal)
+		return ok && number == resolved
+	case port.TargetPort.IntVal != 0:
+		return number == port.TargetPort.IntVal
+	}
+	return number == port.Port
+}
+
+// getSliceBackends aggregates the ready endpoints of every EndpointSlice of the service and returns them with
+// the number of slices found. Endpoints that are still serving while terminating are not counted as ready.
+func getSliceBackends(service *corev1.Service, clientset kubernetes.Interface) ([]backend, int, error) {
+	sliceList, err := clientset.DiscoveryV1().EndpointSlices(service.Namespace).List(context.Background(), metav1.ListOptions{
+		LabelSelector: discoveryv1.LabelServiceName + "=" + service.Name,
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	var backends []backend
+	for _, slice := range sliceList.Items {
+		ports := make(map[string]int32)
+		for _, port := range slice.Ports {
+			if port.Port == nil {
+				continue
+			}
+			name := ""
+			if port.Name != nil {
+				name = *port.Name
+			}
+			ports[name] = *port.Port
+		}
+		for _, endpoint := range slice.Endpoints {
+			if len(endpoint.Addresses) == 0 {
+				continue
+			}
+			if endpoint.Conditions.Ready != nil && !*endpoint.Conditions.Ready {
+				if endpoint.Conditions.Terminating != nil && *endpoint.Conditions.Terminating {
+					logger.AppLog.LogDebug("endpoint %s of service %s is terminating\n", endpoint.Addresses[0], service.Name)
+				}
+				continue
+			}
+			hostname := ""
+			if endpoint.Hostname != nil {
+				hostname = *endpoint.Hostname
+			}
+			backends = append(backends, backend{Address: endpoint.Addresses[0], Hostname: hostname, Pod: podName(endpoint.TargetRef), Ports: ports})
+		}
+	}
+	return backends, len(sliceList.Items), nil
+}
+
+func getEndpointsBackends(service *corev1.Service, clientset kubernetes.Interface) ([]backend, error) {
+	endpoint, err := clientset.CoreV1().Endpoints(service.Namespace).Get(context.Background(), service.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	var backends []backend
+	for _, subset := range endpoint.Subsets {
+		ports := make(map[string]int32)
+		for _, port := range subset.Ports {
+			ports[port.Name] = port.Port
+		}
+		for _, address := range subset.Addresses {
+			backends = append(backends, backend{Address: address.IP, Hostname: address.Hostname, Pod: podName(address.TargetRef), Ports: ports})
+		}
+	}
+	return backends, nil
+}
+
+// getBackends reads EndpointSlices and falls back to the legacy Endpoints API when the cluster doesn't serve
+// discovery.k8s.io/v1 or no slices exist for the service.
+func getBackends(service *corev1.Service, clientset kubernetes.Interface) ([]backend, error) {
+	backends, slices, err := getSliceBackends(service, clientset)
+	if err != nil {
+		if !apierrors.IsNotFound(err) && !apierrors.IsMethodNotSupported(err) {
+			return nil, err
+		}
+		logger.AppLog.LogDebug("endpointslices not available, falling back to endpoints for service %s\n", service.Name)
+		return getEndpointsBackends(service, clientset)
+	}
+	if slices == 0 {
+		return getEndpointsBackends(service, clientset)
+	}
+	return backends, nil
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/vprashar2929/integration-test/pkg/logger"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newEndpointSlice(name, portName string, port int32, endpoints ...discoveryv1.Endpoint) *discoveryv1.EndpointSlice {
+	return &discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: testNS,
+			Labels:    map[string]string{discoveryv1.LabelServiceName: testSvc},
+		},
+		AddressType: discoveryv1.AddressTypeIPv4,
+		Ports: []discoveryv1.EndpointPort{
+			{Name: &portName, Port: &port},
+		},
+		Endpoints: endpoints,
+	}
+}
+
+func newSliceEndpoint(address string, ready, terminating bool) discoveryv1.Endpoint {
+	return discoveryv1.Endpoint{
+		Addresses: []string{address},
+		Conditions: discoveryv1.EndpointConditions{
+			Ready:       &ready,
+			Serving:     &ready,
+			Terminating: &terminating,
+		},
+	}
+}
+
+func TestGetSliceBackendsAggregatesSlices(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	service := testSvcList.Items[0]
+	clientset := fake.NewSimpleClientset(
+		newEndpointSlice("test-service-abc", "TCP", 9090, newSliceEndpoint("10.0.0.1", true, false), newSliceEndpoint("10.0.0.2", false, true)),
+		newEndpointSlice("test-service-def", "TCP", 9090, newSliceEndpoint("10.0.0.3", true, false)),
+	)
+	backends, slices, err := getSliceBackends(&service, clientset)
+	if err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	if slices != 2 || len(backends) != 2 {
+		t.Fatalf("expected 2 ready backends, got: %v", backends)
+	}
+}
+
+func TestCheckServiceEndpointsSlices(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	service := testSvcList.Items[0]
+	clientset := fake.NewSimpleClientset(newEndpointSlice("test-service-abc", "TCP", 9090, newSliceEndpoint("10.0.0.1", true, false)))
+	if err := checkServiceEndpoints(&service, clientset); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+}
+
+func TestCheckServiceEndpointsSlicesOnlyTerminating(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	service := testSvcList.Items[0]
+	clientset := fake.NewSimpleClientset(
+		newEndpointSlice("test-service-abc", "TCP", 9090, newSliceEndpoint("10.0.0.1", false, true)),
+		&testEndpointList.Items[0],
+	)
+	// slices exist, so the stale Endpoints object must not be consulted
+	if err := checkServiceEndpoints(&service, clientset); err == nil {
+		t.Fatalf("expected error, got: %v", err)
+	}
+}
+
+func TestCheckServiceEndpointsTargetPort(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	service := *testSvcList.Items[0].DeepCopy()
+	service.Spec.Ports[0].TargetPort = intstr.FromInt(8080)
+	clientset := fake.NewSimpleClientset(newEndpointSlice("test-service-abc", "TCP", 8080, newSliceEndpoint("10.0.0.1", true, false)))
+	if err := checkServiceEndpoints(&service, clientset); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+
+	service.Spec.Ports[0].TargetPort = intstr.FromInt(8081)
+	if err := checkServiceEndpoints(&service, clientset); err != ErrServiceNotHealthy {
+		t.Fatalf("expected ErrServiceNotHealthy, got: %v", err)
+	}
+
+	service.Spec.Ports[0].TargetPort = intstr.FromString("http")
+	if err := checkServiceEndpoints(&service, clientset); err != nil {
+		t.Fatalf("expected nil for named target port without a backing pod, got: %v", err)
+	}
+}
+
+func TestCheckServiceEndpointsNamedTargetPort(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	service := *testSvcList.Items[0].DeepCopy()
+	service.Spec.Ports[0].TargetPort = intstr.FromString("http")
+	endpoint := newSliceEndpoint("10.0.0.1", true, false)
+	endpoint.TargetRef = &corev1.ObjectReference{Kind: "Pod", Name: "web-0", Namespace: testNS}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-0", Namespace: testNS},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "web", Ports: []corev1.ContainerPort{{Name: "http", ContainerPort: 8080}}}},
+		},
+	}
+	clientset := fake.NewSimpleClientset(pod, newEndpointSlice("test-service-abc", "TCP", 8080, endpoint))
+	if err := checkServiceEndpoints(&service, clientset); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+
+	clientset = fake.NewSimpleClientset(pod, newEndpointSlice("test-service-abc", "TCP", 9090, endpoint))
+	if err := checkServiceEndpoints(&service, clientset); err != ErrServiceNotHealthy {
+		t.Fatalf("expected ErrServiceNotHealthy when the named port resolves elsewhere, got: %v", err)
+	}
+}
+
+func TestGetBackendsFallbackToEndpoints(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	service := testSvcList.Items[0]
+	clientset := fake.NewSimpleClientset(&testEndpointList)
+	backends, err := getBackends(&service, clientset)
+	if err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	if len(backends) != 1 || backends[0].Hostname != "host1" {
+		t.Fatalf("expected backend from endpoints, got: %v", backends)
+	}
+}
+
+func TestPortMatches(t *testing.T) {
+	port := corev1.ServicePort{Port: 80}
+	if !portMatches(port, 80, nil) || portMatches(port, 8080, nil) {
+		t.Errorf("expected port without target port to match service port")
+	}
+}
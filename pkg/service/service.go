@@ -8,8 +8,10 @@ import (
 	"errors"
 
 	"github.com/vprashar2929/integration-test/pkg/logger"
+	"github.com/vprashar2929/integration-test/pkg/metrics"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/util/retry"
@@ -70,27 +72,81 @@ func storeServicesByNamespace(namespaces []string, clientset kubernetes.Interfac
 	}
 	return servicesByNamespace, nil
 }
+func checkServiceEndpoints(service *corev1.Service, clientset kubernetes.Interface) error {
+	backends, err := getBackends(service, clientset)
+	if err != nil {
+		if len(service.Spec.Selector) == 0 {
+			logger.AppLog.LogError("service %s has no selector and no manually managed endpoints\n", service.Name)
+		}
+		return err
+	}
+	pods := make(map[string]*corev1.Pod)
+	backingPod := func(name string) (*corev1.Pod, error) {
+		if name == "" {
+			return nil, nil
+		}
+		if pod, ok := pods[name]; ok {
+			return pod, nil
+		}
+		pod, err := clientset.CoreV1().Pods(service.Namespace).Get(context.Background(), name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		pods[name] = pod
+		return pod, nil
+	}
+	for _, port := range service.Spec.Ports {
+		served := false
+		for _, backend := range backends {
+			number, ok := backend.Ports[port.Name]
+			if !ok {
+				continue
+			}
+			var pod *corev1.Pod
+			if port.TargetPort.Type == intstr.String {
+				if pod, err = backingPod(backend.Pod); err != nil {
+					return err
+				}
+			}
+			if portMatches(port, number, pod) {
+				served = true
+				break
+			}
+		}
+		if !served {
+			logger.AppLog.LogError("port %s/%d of service %s has no ready endpoints\n", port.Name, port.Port, service.Name)
+			return ErrServiceNotHealthy
+		}
+	}
+	return nil
+}
 func checkServiceStatus(namespace string, service corev1.Service, clientset kubernetes.Interface) error {
 	return retryer.RetryOnConflict(retry.DefaultRetry, func() error {
 		updatedService, err := clientset.CoreV1().Services(namespace).Get(context.Background(), service.Name, metav1.GetOptions{})
 		if err != nil {
 			return err
 		}
-		endpoint, err := clientset.CoreV1().Endpoints(namespace).Get(context.Background(), service.Name, metav1.GetOptions{})
-		if err != nil {
+		switch updatedService.Spec.Type {
+		case corev1.ServiceTypeExternalName:
+			err = checkExternalNameService(updatedService)
+		case corev1.ServiceTypeLoadBalancer:
+			err = checkLoadBalancerService(updatedService)
+		case corev1.ServiceTypeNodePort:
+			err = checkNodePorts(updatedService)
+		}
+		if err != nil || updatedService.Spec.Type == corev1.ServiceTypeExternalName {
 			return err
 		}
-		for _, port := range updatedService.Spec.Ports {
-			for _, subset := range endpoint.Subsets {
-				for _, endpointPort := range subset.Ports {
-					if endpointPort.Name == port.Name && endpointPort.Port == port.Port && len(subset.Addresses) > 0 {
-						logger.AppLog.LogInfo("service %s is available in namespace %s\n", service.Name, namespace)
-						return nil
-					}
-				}
-			}
+		if isHeadless(updatedService) {
+			err = checkHeadlessService(updatedService, clientset)
+		} else {
+			err = checkServiceEndpoints(updatedService, clientset)
+		}
+		if err != nil {
+			return err
 		}
-		return ErrServiceNotHealthy
+		logger.AppLog.LogInfo("service %s is available in namespace %s\n", service.Name, namespace)
+		return nil
 	})
 }
 func validateServicesByNamespace(namespaces []string, serviceByNamespace map[string][]corev1.Service, clientset kubernetes.Interface, interval, timeout time.Duration) error {
@@ -108,8 +164,11 @@ func validateServicesByNamespace(namespaces []string, serviceByNamespace map[str
 				time.Sleep(interval)
 			}
 			if time.Now().After(deadline) {
-				return fmt.Errorf("timeout checking service status for %s in namespace %s, error: %v", service.Name, namespace, err)
+				err = fmt.Errorf("timeout checking service status for %s in namespace %s, error: %v", service.Name, namespace, err)
+				metrics.ObserveObject("Service", namespace, service.Name, err)
+				return err
 			}
+			metrics.ObserveObject("Service", namespace, service.Name, nil)
 		}
 	}
 	return nil
@@ -134,3 +193,12 @@ func CheckServices(namespaces []string, clientset kubernetes.Interface, interval
 	return nil
 
 }
+func GetServiceStatus(namespace string, name string, clientset kubernetes.Interface) error {
+	logger.AppLog.LogInfo("Checking service %s status\n", name)
+	service, err := clientset.CoreV1().Services(namespace).Get(context.Background(), name, metav1.GetOptions{})
+	if err != nil {
+		logger.AppLog.LogError("cannot get service %s in namespace %s: %v\n", name, namespace, err)
+		return ErrNoService
+	}
+	return checkServiceStatus(namespace, *service, clientset)
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/vprashar2929/integration-test/pkg/logger"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Resolver looks up hosts in DNS. It is swapped out in unit tests so ExternalName checks don't depend on the network.
+type Resolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+var resolver Resolver = net.DefaultResolver
+
+var (
+	ErrLoadBalancerPending      = errors.New("error load balancer ingress not assigned to service")
+	ErrNodePortNotAllocated     = errors.New("error node port not allocated for service")
+	ErrExternalNameUnresolvable = errors.New("error external name of service cannot be resolved")
+	ErrNoEndpoints              = errors.New("error service has no ready endpoints")
+)
+
+func isHeadless(service *corev1.Service) bool {
+	return service.Spec.ClusterIP == corev1.ClusterIPNone
+}
+
+func checkExternalNameService(service *corev1.Service) error {
+	addrs, err := resolver.LookupHost(context.Background(), service.Spec.ExternalName)
+	if err != nil || len(addrs) == 0 {
+		logger.AppLog.LogError("cannot resolve external name %s of service %s: %v\n", service.Spec.ExternalName, service.Name, err)
+		return ErrExternalNameUnresolvable
+	}
+	logger.AppLog.LogDebug("external name %s of service %s resolves to %v\n", service.Spec.ExternalName, service.Name, addrs)
+	return nil
+}
+
+func checkNodePorts(service *corev1.Service) error {
+	if service.Spec.Type == corev1.ServiceTypeLoadBalancer && service.Spec.AllocateLoadBalancerNodePorts != nil &&
+		!*service.Spec.AllocateLoadBalancerNodePorts {
+		return nil
+	}
+	for _, port := range service.Spec.Ports {
+		if port.NodePort == 0 {
+			return fmt.Errorf("%w: port %s/%d", ErrNodePortNotAllocated, port.Name, port.Port)
+		}
+	}
+	return nil
+}
+
+func checkLoadBalancerService(service *corev1.Service) error {
+	for _, ingress := range service.Status.LoadBalancer.Ingress {
+		if ingress.IP != "" || ingress.Hostname != "" {
+			return checkNodePorts(service)
+		}
+	}
+	return ErrLoadBalancerPending
+}
+
+// checkHeadlessService makes sure a headless service has ready addresses. Addresses carrying a hostname get
+// per-pod DNS records of the form <hostname>.<service>.<namespace>.svc
+func checkHeadlessService(service *corev1.Service, clientset kubernetes.Interface) error {
+	backends, err := getBackends(service, clientset)
+	if err != nil {
+		return err
+	}
+	if len(backends) == 0 {
+		return ErrNoEndpoints
+	}
+	for _, backend := range backends {
+		if backend.Hostname == "" {
+			logger.AppLog.LogWarning("address %s of headless service %s has no per-pod DNS record, check pod hostname/subdomain\n", backend.Address, service.Name)
+			continue
+		}
+		logger.AppLog.LogDebug("headless service %s serves %s.%s.%s.svc\n", service.Name, backend.Hostname, service.Name, service.Namespace)
+	}
+	return nil
+}
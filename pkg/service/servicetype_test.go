@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/vprashar2929/integration-test/pkg/logger"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+type mockResolver struct {
+	addrs map[string][]string
+}
+
+func (m *mockResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	addrs, ok := m.addrs[host]
+	if !ok {
+		return nil, errors.New("no such host")
+	}
+	return addrs, nil
+}
+
+func newTypedService(serviceType corev1.ServiceType) corev1.Service {
+	service := *testSvcList.Items[0].DeepCopy()
+	service.Spec.Type = serviceType
+	return service
+}
+
+func TestCheckServiceStatusExternalName(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	resolver = &mockResolver{addrs: map[string][]string{"db.example.com": {"192.0.2.10"}}}
+	service := newTypedService(corev1.ServiceTypeExternalName)
+	service.Spec.ExternalName = "db.example.com"
+	clientset := fake.NewSimpleClientset(&service)
+	if err := checkServiceStatus(testNS, service, clientset); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+}
+
+func TestCheckServiceStatusExternalNameUnresolvable(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	resolver = &mockResolver{}
+	service := newTypedService(corev1.ServiceTypeExternalName)
+	service.Spec.ExternalName = "missing.example.com"
+	clientset := fake.NewSimpleClientset(&service)
+	if err := checkServiceStatus(testNS, service, clientset); err != ErrExternalNameUnresolvable {
+		t.Fatalf("expected ErrExternalNameUnresolvable, got: %v", err)
+	}
+}
+
+func TestCheckServiceStatusLoadBalancerPending(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	service := newTypedService(corev1.ServiceTypeLoadBalancer)
+	service.Spec.Ports[0].NodePort = 30090
+	clientset := fake.NewSimpleClientset(&service, &testEndpointList)
+	if err := checkServiceStatus(testNS, service, clientset); err != ErrLoadBalancerPending {
+		t.Fatalf("expected ErrLoadBalancerPending, got: %v", err)
+	}
+}
+
+func TestCheckServiceStatusLoadBalancer(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	service := newTypedService(corev1.ServiceTypeLoadBalancer)
+	service.Spec.Ports[0].NodePort = 30090
+	service.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "203.0.113.5"}}
+	clientset := fake.NewSimpleClientset(&service, &testEndpointList)
+	if err := checkServiceStatus(testNS, service, clientset); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+}
+
+func TestCheckServiceStatusNodePortNotAllocated(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	service := newTypedService(corev1.ServiceTypeNodePort)
+	clientset := fake.NewSimpleClientset(&service, &testEndpointList)
+	if err := checkServiceStatus(testNS, service, clientset); !errors.Is(err, ErrNodePortNotAllocated) {
+		t.Fatalf("expected ErrNodePortNotAllocated, got: %v", err)
+	}
+}
+
+func TestCheckServiceStatusHeadless(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	service := newTypedService(corev1.ServiceTypeClusterIP)
+	service.Spec.ClusterIP = corev1.ClusterIPNone
+	clientset := fake.NewSimpleClientset(&service, &testEndpointList)
+	if err := checkServiceStatus(testNS, service, clientset); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+}
+
+func TestCheckServiceStatusHeadlessNoEndpoints(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	service := newTypedService(corev1.ServiceTypeClusterIP)
+	service.Spec.ClusterIP = corev1.ClusterIPNone
+	emptyEndpoints := corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testSvc,
+			Namespace: testNS,
+		},
+	}
+	clientset := fake.NewSimpleClientset(&service, &emptyEndpoints)
+	if err := checkServiceStatus(testNS, service, clientset); err != ErrNoEndpoints {
+		t.Fatalf("expected ErrNoEndpoints, got: %v", err)
+	}
+}
+
+func TestCheckServiceStatusSelectorlessNoEndpoints(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	service := newTypedService(corev1.ServiceTypeClusterIP)
+	clientset := fake.NewSimpleClientset(&service)
+	if err := checkServiceStatus(testNS, service, clientset); err == nil {
+		t.Fatalf("expected error, got: %v", err)
+	}
+}
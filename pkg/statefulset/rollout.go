@@ -0,0 +1,176 @@
+package statefulset
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/vprashar2929/integration-test/pkg/hpa"
+	"github.com/vprashar2929/integration-test/pkg/logger"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+)
+
+var (
+	ErrStatefulSetRevision    = errors.New("statefulset pod not at expected revision")
+	ErrStatefulSetPodNotReady = errors.New("statefulset pod not ready")
+	ErrPVCNotBound            = errors.New("statefulset persistent volume claim not bound")
+	ErrMaxUnavailableExceeded = errors.New("statefulset has more unavailable pods than allowed by maxUnavailable")
+)
+
+func desiredReplicas(statefulset *appsv1.StatefulSet) int32 {
+	if statefulset.Spec.Replicas == nil {
+		return 1
+	}
+	return *statefulset.Spec.Replicas
+}
+
+// expectedReplicas returns the replicas the statefulset has to run. An autoscaler owns spec.replicas, the
+// statefulset is re-read on every attempt so changes made while waiting are picked up, and a spec.replicas
+// beyond the autoscaler maximum is about to be scaled down.
+func expectedReplicas(statefulset *appsv1.StatefulSet, clientset kubernetes.Interface) int32 {
+	replicas := desiredReplicas(statefulset)
+	_, maxReplicas, managed, err := hpa.GetReplicaBounds(statefulset.Namespace, "StatefulSet", statefulset.Name, clientset)
+	if err != nil {
+		logger.AppLog.LogDebug("cannot look up autoscaler for statefulset %s, err: %v\n", statefulset.Name, err)
+	}
+	if managed && replicas > maxReplicas {
+		replicas = maxReplicas
+	}
+	return replicas
+}
+
+func startOrdinal(statefulset *appsv1.StatefulSet) int32 {
+	if statefulset.Spec.Ordinals == nil {
+		return 0
+	}
+	return statefulset.Spec.Ordinals.Start
+}
+
+// partition returns the replica index, counted from the start ordinal, from which pods are expected to run the
+// update revision. OnDelete statefulsets return -1 as pods are only updated when they are deleted manually.
+func partition(statefulset *appsv1.StatefulSet) int32 {
+	if statefulset.Spec.UpdateStrategy.Type == appsv1.OnDeleteStatefulSetStrategyType {
+		return -1
+	}
+	rollingUpdate := statefulset.Spec.UpdateStrategy.RollingUpdate
+	if rollingUpdate == nil || rollingUpdate.Partition == nil {
+		return 0
+	}
+	return *rollingUpdate.Partition
+}
+
+// maxUnavailable returns how many pods may be unavailable, rounded up with a minimum of 1 like the controller does
+func maxUnavailable(statefulset *appsv1.StatefulSet) (int, error) {
+	rollingUpdate := statefulset.Spec.UpdateStrategy.RollingUpdate
+	if statefulset.Spec.UpdateStrategy.Type == appsv1.OnDeleteStatefulSetStrategyType ||
+		rollingUpdate == nil || rollingUpdate.MaxUnavailable == nil {
+		return 1, nil
+	}
+	allowed, err := intstr.GetScaledValueFromIntOrPercent(rollingUpdate.MaxUnavailable, int(desiredReplicas(statefulset)), true)
+	if err != nil {
+		return 0, err
+	}
+	if allowed < 1 {
+		allowed = 1
+	}
+	return allowed, nil
+}
+
+// checkRevisions validates status revisions against the update strategy. With a partition in place
+// currentRevision and updateRevision are expected to differ, so only the updated replica count is compared.
+func checkRevisions(statefulset *appsv1.StatefulSet) error {
+	replicas := desiredReplicas(statefulset)
+	partitionOrdinal := partition(statefulset)
+	switch {
+	case partitionOrdinal < 0:
+		if statefulset.Status.CurrentRevision != statefulset.Status.UpdateRevision {
+			logger.AppLog.LogWarning("statefulset %s uses OnDelete strategy and has pods waiting to be deleted to pick up revision %s\n",
+				statefulset.Name, statefulset.Status.UpdateRevision)
+		}
+		return nil
+	case partitionOrdinal == 0:
+		if statefulset.Status.CurrentRevision != statefulset.Status.UpdateRevision ||
+			statefulset.Status.UpdatedReplicas != replicas {
+			return fmt.Errorf("%w: current revision %s, update revision %s, %d/%d replicas updated", ErrStatefulSetRevision,
+				statefulset.Status.CurrentRevision, statefulset.Status.UpdateRevision, statefulset.Status.UpdatedReplicas, replicas)
+		}
+	default:
+		expected := replicas - partitionOrdinal
+		if expected < 0 {
+			expected = 0
+		}
+		if expected > replicas {
+			expected = replicas
+		}
+		if statefulset.Status.UpdatedReplicas < expected {
+			return fmt.Errorf("%w: partition %d expects %d replicas updated, got %d", ErrStatefulSetRevision,
+				partitionOrdinal, expected, statefulset.Status.UpdatedReplicas)
+		}
+	}
+	return nil
+}
+
+func isPodReady(pod *corev1.Pod) bool {
+	if pod.Status.Phase != corev1.PodRunning {
+		return false
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// checkOrdinals walks every ordinal of the statefulset and verifies that the pod exists, runs the revision
+// expected by the update strategy and that the claims created from volumeClaimTemplates are bound. Up to
+// maxUnavailable pods may be not ready, as the controller takes them down while rolling out.
+func checkOrdinals(statefulset *appsv1.StatefulSet, clientset kubernetes.Interface) error {
+	replicas := expectedReplicas(statefulset, clientset)
+	start := startOrdinal(statefulset)
+	partitionOrdinal := partition(statefulset)
+	allowedUnavailable, err := maxUnavailable(statefulset)
+	if err != nil {
+		return err
+	}
+
+	var notReady []string
+	for ordinal := start; ordinal < start+replicas; ordinal++ {
+		podName := fmt.Sprintf("%s-%d", statefulset.Name, ordinal)
+		pod, err := clientset.CoreV1().Pods(statefulset.Namespace).Get(context.TODO(), podName, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("%w: cannot get pod %s: %v", ErrStatefulSetPodNotReady, podName, err)
+		}
+		if !isPodReady(pod) {
+			notReady = append(notReady, podName)
+		}
+		revision := pod.Labels[appsv1.StatefulSetRevisionLabel]
+		// the controller applies the partition to the replica index, not to the ordinal in the pod name
+		if partitionOrdinal >= 0 && ordinal-start >= partitionOrdinal && statefulset.Status.UpdateRevision != "" &&
+			revision != statefulset.Status.UpdateRevision {
+			return fmt.Errorf("%w: pod %s has revision %s, expected %s", ErrStatefulSetRevision,
+				podName, revision, statefulset.Status.UpdateRevision)
+		}
+		for _, template := range statefulset.Spec.VolumeClaimTemplates {
+			claimName := fmt.Sprintf("%s-%s", template.Name, podName)
+			claim, err := clientset.CoreV1().PersistentVolumeClaims(statefulset.Namespace).Get(context.TODO(), claimName, metav1.GetOptions{})
+			if err != nil {
+				return fmt.Errorf("%w: cannot get claim %s: %v", ErrPVCNotBound, claimName, err)
+			}
+			if claim.Status.Phase != corev1.ClaimBound {
+				return fmt.Errorf("%w: claim %s is %s", ErrPVCNotBound, claimName, claim.Status.Phase)
+			}
+		}
+	}
+	if len(notReady) > allowedUnavailable {
+		return fmt.Errorf("%w: %d allowed, not ready: %v", ErrMaxUnavailableExceeded, allowedUnavailable, notReady)
+	}
+	if len(notReady) > 0 {
+		logger.AppLog.LogWarning("statefulset %s has pods not ready within maxUnavailable %d: %v\n", statefulset.Name, allowedUnavailable, notReady)
+	}
+	return nil
+}
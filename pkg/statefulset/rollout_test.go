@@ -0,0 +1,218 @@
+package statefulset
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/vprashar2929/integration-test/pkg/logger"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newPartitionedStatefulSet(replicas, partition int32, status appsv1.StatefulSetStatus) *appsv1.StatefulSet {
+	return &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testStset,
+			Namespace: testNS,
+		},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas: &replicas,
+			UpdateStrategy: appsv1.StatefulSetUpdateStrategy{
+				Type: appsv1.RollingUpdateStatefulSetStrategyType,
+				RollingUpdate: &appsv1.RollingUpdateStatefulSetStrategy{
+					Partition: &partition,
+				},
+			},
+			VolumeClaimTemplates: []corev1.PersistentVolumeClaim{
+				{ObjectMeta: metav1.ObjectMeta{Name: "data"}},
+			},
+		},
+		Status: status,
+	}
+}
+
+func newOrdinalObjects(statefulset *appsv1.StatefulSet, revisions []string, claimPhase corev1.PersistentVolumeClaimPhase) []runtime.Object {
+	var objects []runtime.Object
+	for index, revision := range revisions {
+		podName := fmt.Sprintf("%s-%d", statefulset.Name, startOrdinal(statefulset)+int32(index))
+		objects = append(objects, &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      podName,
+				Namespace: testNS,
+				Labels:    map[string]string{appsv1.StatefulSetRevisionLabel: revision},
+			},
+			Status: corev1.PodStatus{
+				Phase: corev1.PodRunning,
+				Conditions: []corev1.PodCondition{
+					{Type: corev1.PodReady, Status: corev1.ConditionTrue},
+				},
+			},
+		}, &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "data-" + podName,
+				Namespace: testNS,
+			},
+			Status: corev1.PersistentVolumeClaimStatus{
+				Phase: claimPhase,
+			},
+		})
+	}
+	return objects
+}
+
+func TestCheckRevisionsPartitioned(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	sts := newPartitionedStatefulSet(3, 2, appsv1.StatefulSetStatus{
+		CurrentRevision: "rev-1",
+		UpdateRevision:  "rev-2",
+		UpdatedReplicas: 1,
+	})
+	if err := checkRevisions(sts); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+}
+
+func TestCheckRevisionsNotUpdated(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	sts := newPartitionedStatefulSet(3, 0, appsv1.StatefulSetStatus{
+		CurrentRevision: "rev-1",
+		UpdateRevision:  "rev-2",
+		UpdatedReplicas: 2,
+	})
+	if err := checkRevisions(sts); !errors.Is(err, ErrStatefulSetRevision) {
+		t.Fatalf("expected ErrStatefulSetRevision, got: %v", err)
+	}
+}
+
+func TestCheckRevisionsOnDelete(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	sts := newPartitionedStatefulSet(3, 0, appsv1.StatefulSetStatus{
+		CurrentRevision: "rev-1",
+		UpdateRevision:  "rev-2",
+	})
+	sts.Spec.UpdateStrategy = appsv1.StatefulSetUpdateStrategy{Type: appsv1.OnDeleteStatefulSetStrategyType}
+	if err := checkRevisions(sts); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+}
+
+func TestCheckOrdinals(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	sts := newPartitionedStatefulSet(3, 2, appsv1.StatefulSetStatus{
+		CurrentRevision: "rev-1",
+		UpdateRevision:  "rev-2",
+	})
+	clientset := fake.NewSimpleClientset(newOrdinalObjects(sts, []string{"rev-1", "rev-1", "rev-2"}, corev1.ClaimBound)...)
+	if err := checkOrdinals(sts, clientset); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+}
+
+func TestCheckOrdinalsStartOrdinal(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	sts := newPartitionedStatefulSet(3, 2, appsv1.StatefulSetStatus{
+		CurrentRevision: "rev-1",
+		UpdateRevision:  "rev-2",
+		UpdatedReplicas: 1,
+	})
+	sts.Spec.Ordinals = &appsv1.StatefulSetOrdinals{Start: 5}
+	// pods 5 and 6 are below the partition, only pod 7 runs the update revision
+	clientset := fake.NewSimpleClientset(newOrdinalObjects(sts, []string{"rev-1", "rev-1", "rev-2"}, corev1.ClaimBound)...)
+	if err := checkOrdinals(sts, clientset); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	if err := checkRevisions(sts); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+}
+
+func TestCheckOrdinalsWrongRevision(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	sts := newPartitionedStatefulSet(3, 1, appsv1.StatefulSetStatus{
+		CurrentRevision: "rev-1",
+		UpdateRevision:  "rev-2",
+	})
+	clientset := fake.NewSimpleClientset(newOrdinalObjects(sts, []string{"rev-1", "rev-1", "rev-2"}, corev1.ClaimBound)...)
+	if err := checkOrdinals(sts, clientset); !errors.Is(err, ErrStatefulSetRevision) {
+		t.Fatalf("expected ErrStatefulSetRevision, got: %v", err)
+	}
+}
+
+func TestCheckOrdinalsClaimPending(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	sts := newPartitionedStatefulSet(2, 0, appsv1.StatefulSetStatus{
+		CurrentRevision: "rev-1",
+		UpdateRevision:  "rev-1",
+	})
+	clientset := fake.NewSimpleClientset(newOrdinalObjects(sts, []string{"rev-1", "rev-1"}, corev1.ClaimPending)...)
+	if err := checkOrdinals(sts, clientset); !errors.Is(err, ErrPVCNotBound) {
+		t.Fatalf("expected ErrPVCNotBound, got: %v", err)
+	}
+}
+
+func TestMaxUnavailableRoundsUp(t *testing.T) {
+	sts := newPartitionedStatefulSet(5, 0, appsv1.StatefulSetStatus{})
+	percent := intstr.FromString("10%")
+	sts.Spec.UpdateStrategy.RollingUpdate.MaxUnavailable = &percent
+	if allowed, err := maxUnavailable(sts); err != nil || allowed != 1 {
+		t.Fatalf("expected 1, got: %d %v", allowed, err)
+	}
+	percent = intstr.FromString("30%")
+	if allowed, err := maxUnavailable(sts); err != nil || allowed != 2 {
+		t.Fatalf("expected 2, got: %d %v", allowed, err)
+	}
+}
+
+func TestCheckOrdinalsMaxUnavailable(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	sts := newPartitionedStatefulSet(3, 0, appsv1.StatefulSetStatus{
+		CurrentRevision: "rev-1",
+		UpdateRevision:  "rev-1",
+	})
+	objects := newOrdinalObjects(sts, []string{"rev-1", "rev-1", "rev-1"}, corev1.ClaimBound)
+	// objects alternate pod and claim, the first pod is not ready
+	objects[0].(*corev1.Pod).Status.Conditions[0].Status = corev1.ConditionFalse
+	if err := checkOrdinals(sts, fake.NewSimpleClientset(objects...)); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	objects[2].(*corev1.Pod).Status.Conditions[0].Status = corev1.ConditionFalse
+	if err := checkOrdinals(sts, fake.NewSimpleClientset(objects...)); !errors.Is(err, ErrMaxUnavailableExceeded) {
+		t.Fatalf("expected ErrMaxUnavailableExceeded, got: %v", err)
+	}
+}
+
+func TestExpectedReplicasAutoscaled(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	sts := newPartitionedStatefulSet(6, 0, appsv1.StatefulSetStatus{})
+	if replicas := expectedReplicas(sts, fake.NewSimpleClientset()); replicas != 6 {
+		t.Fatalf("expected 6 replicas without autoscaler, got: %d", replicas)
+	}
+	autoscaler := &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-hpa", Namespace: testNS},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "StatefulSet", Name: testStset},
+			MaxReplicas:    4,
+		},
+	}
+	if replicas := expectedReplicas(sts, fake.NewSimpleClientset(autoscaler)); replicas != 4 {
+		t.Fatalf("expected the autoscaler maximum, got: %d", replicas)
+	}
+}
+
+func TestCheckOrdinalsMissingPod(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	sts := newPartitionedStatefulSet(3, 0, appsv1.StatefulSetStatus{
+		CurrentRevision: "rev-1",
+		UpdateRevision:  "rev-1",
+	})
+	clientset := fake.NewSimpleClientset(newOrdinalObjects(sts, []string{"rev-1", "rev-1"}, corev1.ClaimBound)...)
+	if err := checkOrdinals(sts, clientset); !errors.Is(err, ErrStatefulSetPodNotReady) {
+		t.Fatalf("expected ErrStatefulSetPodNotReady, got: %v", err)
+	}
+}
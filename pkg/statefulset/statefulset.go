@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/vprashar2929/integration-test/pkg/logger"
+	"github.com/vprashar2929/integration-test/pkg/metrics"
 	"github.com/vprashar2929/integration-test/pkg/pod"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -82,12 +83,11 @@ func checkStatefulSetStatus(namespace string, statefulset appsv1.StatefulSet, cl
 		if err != nil {
 			return err
 		}
-		if updatedStatefulSet.Status.UpdatedReplicas == *statefulset.Spec.Replicas &&
-			updatedStatefulSet.Status.Replicas == *statefulset.Spec.Replicas &&
-			updatedStatefulSet.Status.CurrentReplicas == *statefulset.Spec.Replicas &&
-			updatedStatefulSet.Status.ObservedGeneration >= statefulset.Generation {
-			logger.AppLog.LogInfo("statefulset %s is available in namespace %s\n", statefulset.Name, namespace)
-			return nil
+		// pods above an autoscaler maximum may still be terminating
+		replicas := expectedReplicas(updatedStatefulSet, clientset)
+		if updatedStatefulSet.Status.ObservedGeneration < updatedStatefulSet.Generation ||
+			updatedStatefulSet.Status.Replicas < replicas || updatedStatefulSet.Status.Replicas > desiredReplicas(updatedStatefulSet) {
+			return ErrStatefulSetNotHealthy
 		}
 		for _, condition := range updatedStatefulSet.Status.Conditions {
 			if condition.Type == "StatefulSetReplicasReady" && condition.Status == corev1.ConditionFalse {
@@ -95,7 +95,14 @@ func checkStatefulSetStatus(namespace string, statefulset appsv1.StatefulSet, cl
 				return ErrStatefulSetNotHealthy
 			}
 		}
-		return ErrStatefulSetNotHealthy
+		if err = checkRevisions(updatedStatefulSet); err != nil {
+			return err
+		}
+		if err = checkOrdinals(updatedStatefulSet, clientset); err != nil {
+			return err
+		}
+		logger.AppLog.LogInfo("statefulset %s is available in namespace %s\n", statefulset.Name, namespace)
+		return nil
 	})
 }
 func validateStatefulSetsByNamespace(namespaces []string, statefulsetsByNamespace map[string][]appsv1.StatefulSet, clientset kubernetes.Interface, interval, timeout time.Duration) error {
@@ -116,7 +123,9 @@ func validateStatefulSetsByNamespace(namespaces []string, statefulsetsByNamespac
 				time.Sleep(interval)
 			}
 			if time.Now().After(deadline) {
-				return fmt.Errorf("timeout checking statefulset status for %s in namespace %s, error: %v", statefulset.Name, namespace, err)
+				err = fmt.Errorf("timeout checking statefulset status for %s in namespace %s, error: %v", statefulset.Name, namespace, err)
+				metrics.ObserveObject("StatefulSet", namespace, statefulset.Name, err)
+				return err
 			}
 
 			// check pods status
@@ -129,8 +138,11 @@ func validateStatefulSetsByNamespace(namespaces []string, statefulsetsByNamespac
 				time.Sleep(interval)
 			}
 			if time.Now().After(deadline) {
-				return fmt.Errorf("timeout checking pod status for statefulset %s in namespace %s, error: %v", statefulset.Name, namespace, err)
+				err = fmt.Errorf("timeout checking pod status for statefulset %s in namespace %s, error: %v", statefulset.Name, namespace, err)
+				metrics.ObserveObject("StatefulSet", namespace, statefulset.Name, err)
+				return err
 			}
+			metrics.ObserveObject("StatefulSet", namespace, statefulset.Name, nil)
 		}
 	}
 
@@ -154,3 +166,19 @@ func CheckStatefulSets(namespace []string, clientset kubernetes.Interface, inter
 	logger.AppLog.LogInfo("End StatefulSet validation")
 	return nil
 }
+
+// CheckNamedStatefulSets validates the given statefulsets of a namespace, for callers such as release
+// checks that know which statefulsets they own.
+func CheckNamedStatefulSets(namespace string, names []string, clientset kubernetes.Interface, interval, timeout time.Duration) error {
+	var statefulsets []appsv1.StatefulSet
+	for _, name := range names {
+		statefulset, err := clientset.AppsV1().StatefulSets(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			logger.AppLog.LogError("cannot get statefulset %s in namespace %s: %v\n", name, namespace, err)
+			return fmt.Errorf("%w: %s", ErrNoStatefulSet, name)
+		}
+		statefulsets = append(statefulsets, *statefulset)
+	}
+	statefulsetsByNamespace := map[string][]appsv1.StatefulSet{namespace: statefulsets}
+	return validateStatefulSetsByNamespace([]string{namespace}, statefulsetsByNamespace, clientset, interval, timeout)
+}
@@ -1,6 +1,7 @@
 package statefulset
 
 import (
+	"errors"
 	"testing"
 	"time"
 
@@ -319,3 +320,32 @@ func TestCheckStatefulSetsNoNamespace(t *testing.T) {
 		t.Fatalf("expected ErrNamespaceEmpty, got: %v", err)
 	}
 }
+
+func TestCheckNamedStatefulSets(t *testing.T) {
+	testLabels["app"] = "test-app"
+	testPods := corev1.PodList{
+		Items: []corev1.Pod{
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "testPod",
+					Namespace: testNS,
+					Labels:    testLabels,
+				},
+				Status: corev1.PodStatus{
+					Phase: corev1.PodRunning,
+				},
+			},
+		},
+	}
+	clientset := fake.NewSimpleClientset(&testSSList, &testPods)
+	retryer = &mockRetryer{err: nil}
+	logger.NewLogger(logger.LevelInfo)
+	interval := 1 * time.Second
+	timeout := 5 * time.Second
+	if err := CheckNamedStatefulSets(testNS, []string{testStset}, clientset, interval, timeout); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	if err := CheckNamedStatefulSets(testNS, []string{"missing"}, clientset, interval, timeout); !errors.Is(err, ErrNoStatefulSet) {
+		t.Fatalf("expected ErrNoStatefulSet, got: %v", err)
+	}
+}
@@ -0,0 +1,109 @@
+package workload
+
+import (
+	"context"
+	"errors"
+
+	"github.com/vprashar2929/integration-test/pkg/logger"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	KindDeployment  = "Deployment"
+	KindStatefulSet = "StatefulSet"
+	KindDaemonSet   = "DaemonSet"
+)
+
+var (
+	ErrListingWorkload = errors.New("error listing workloads in namespace")
+	ErrNoWorkload      = errors.New("no workloads found in namespace")
+	ErrNoNamespace     = errors.New("no namespace provided")
+)
+
+// Workload is a deployment, statefulset or daemonset along with the labels and spec of its pod template
+type Workload struct {
+	Kind   string
+	Name   string
+	Labels labels.Set
+	Spec   corev1.PodSpec
+}
+
+func wants(kinds []string, kind string) bool {
+	if len(kinds) == 0 {
+		return true
+	}
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// List returns the workloads of the given kinds in the namespace, every kind when none is given
+func List(namespace string, clientset kubernetes.Interface, kinds ...string) ([]Workload, error) {
+	var workloads []Workload
+	if wants(kinds, KindDeployment) {
+		deployments, err := clientset.AppsV1().Deployments(namespace).List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			logger.AppLog.LogError("error listing deployments in namespace %s: %v\n", namespace, err)
+			return nil, ErrListingWorkload
+		}
+		for _, deployment := range deployments.Items {
+			workloads = append(workloads, Workload{Kind: KindDeployment, Name: deployment.Name, Labels: deployment.Spec.Template.Labels, Spec: deployment.Spec.Template.Spec})
+		}
+	}
+	if wants(kinds, KindStatefulSet) {
+		statefulsets, err := clientset.AppsV1().StatefulSets(namespace).List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			logger.AppLog.LogError("error listing statefulsets in namespace %s: %v\n", namespace, err)
+			return nil, ErrListingWorkload
+		}
+		for _, statefulset := range statefulsets.Items {
+			workloads = append(workloads, Workload{Kind: KindStatefulSet, Name: statefulset.Name, Labels: statefulset.Spec.Template.Labels, Spec: statefulset.Spec.Template.Spec})
+		}
+	}
+	if wants(kinds, KindDaemonSet) {
+		daemonsets, err := clientset.AppsV1().DaemonSets(namespace).List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			logger.AppLog.LogError("error listing daemonsets in namespace %s: %v\n", namespace, err)
+			return nil, ErrListingWorkload
+		}
+		for _, daemonset := range daemonsets.Items {
+			workloads = append(workloads, Workload{Kind: KindDaemonSet, Name: daemonset.Name, Labels: daemonset.Spec.Template.Labels, Spec: daemonset.Spec.Template.Spec})
+		}
+	}
+	if len(workloads) == 0 {
+		return nil, ErrNoWorkload
+	}
+	return workloads, nil
+}
+
+// ListByNamespace lists the workloads of every namespace, namespaces without any are left out
+func ListByNamespace(namespaces []string, clientset kubernetes.Interface, kinds ...string) (map[string][]Workload, error) {
+	if len(namespaces) == 0 {
+		return nil, ErrNoNamespace
+	}
+	workloadsByNamespace := make(map[string][]Workload)
+	for _, namespace := range namespaces {
+		if namespace == "" {
+			logger.AppLog.LogError("Invalid namespace provided.")
+			continue
+		}
+		workloads, err := List(namespace, clientset, kinds...)
+		if err != nil {
+			if errors.Is(err, ErrNoWorkload) {
+				continue
+			}
+			return nil, err
+		}
+		workloadsByNamespace[namespace] = workloads
+	}
+	if len(workloadsByNamespace) == 0 {
+		return nil, ErrNoWorkload
+	}
+	return workloadsByNamespace, nil
+}
@@ -0,0 +1,63 @@
+package workload
+
+import (
+	"testing"
+
+	"github.com/vprashar2929/integration-test/pkg/logger"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var testNS = "test-namespace"
+
+func newTestObjects() *fake.Clientset {
+	return fake.NewSimpleClientset(
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: testNS}},
+		&appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: testNS}},
+		&appsv1.DaemonSet{ObjectMeta: metav1.ObjectMeta{Name: "agent", Namespace: testNS}},
+	)
+}
+
+func TestList(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	workloads, err := List(testNS, newTestObjects())
+	if err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	if len(workloads) != 3 || workloads[0].Kind != KindDeployment || workloads[2].Kind != KindDaemonSet {
+		t.Fatalf("expected a deployment, statefulset and daemonset, got: %v", workloads)
+	}
+}
+
+func TestListKinds(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	workloads, err := List(testNS, newTestObjects(), KindStatefulSet)
+	if err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	if len(workloads) != 1 || workloads[0].Name != "db" {
+		t.Fatalf("expected only the statefulset, got: %v", workloads)
+	}
+}
+
+func TestListNoWorkload(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	if _, err := List(testNS, fake.NewSimpleClientset()); err != ErrNoWorkload {
+		t.Fatalf("expected ErrNoWorkload, got: %v", err)
+	}
+}
+
+func TestListByNamespace(t *testing.T) {
+	logger.NewLogger(logger.LevelInfo)
+	workloadsByNamespace, err := ListByNamespace([]string{testNS, "empty"}, newTestObjects())
+	if err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	if _, ok := workloadsByNamespace["empty"]; ok || len(workloadsByNamespace[testNS]) != 3 {
+		t.Fatalf("expected only %s, got: %v", testNS, workloadsByNamespace)
+	}
+	if _, err = ListByNamespace(nil, newTestObjects()); err != ErrNoNamespace {
+		t.Fatalf("expected ErrNoNamespace, got: %v", err)
+	}
+}